@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway/session"
 )
 
@@ -23,12 +26,40 @@ type Bot interface {
 	GetSessionByGuildID(guildID structs.Snowflake) session.ClientSession
 	RegisterCommands(commands map[string]session.CommandFunc)
 	RegisterListeners(listeners map[session.Listener]session.CommandFunc)
+	Use(middlewares ...session.Middleware)
+	GetDispatcherStats() map[int]session.DispatcherStats
+	OnError(handler func(session.ErrorContext))
+	SetCommandRouter(router *session.CommandRouter)
+	SyncCommands(opts session.SyncOptions) (*session.SyncPlan, error)
+	GetCache() cache.CacheStore
+	GetCacheStats() cache.Stats
+	GetGuild(guildID structs.Snowflake) (*structs.Guild, error)
+	GetChannel(channelID structs.Snowflake) (*structs.Channel, error)
+	GetMember(guildID, userID structs.Snowflake) (*structs.GuildMember, error)
+	GetSelfPermissions(channelID structs.Snowflake) (structs.Permission, error)
+	CanSend(channelID structs.Snowflake) bool
+	RequestGuildMembers(ctx context.Context, guildID structs.Snowflake, request session.GuildMembersRequest) (*session.GuildMembersResult, error)
+	SetPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error
+	Latency() time.Duration
 }
 
 type bot struct {
 	mu *sync.Mutex
 
 	sessions map[int]session.ClientSession
+	cache    cache.CacheStore
+
+	dispatcherOpts *session.DispatcherOptions
+	cacheOpts      cache.Options
+	router         *session.CommandRouter
+	chunkGuilds    bool
+	presence       *initialPresence
+}
+
+// initialPresence is the presence set with `WithPresence`.
+type initialPresence struct {
+	status     structs.UserStatusType
+	activities []structs.Activity
 }
 
 var _ Bot = (*bot)(nil)
@@ -43,6 +74,7 @@ var _ Bot = (*bot)(nil)
 //   - version: The version of the bot. You can set this as an empty string, for a default value of 1.
 //   - token: The bot token used for authentication with the Discord API.
 //   - intents: A slice of intents specifying the intents the bot needs to have in order to function.
+//   - opts: Optional configuration applied to every session, i.e `bot.WithDispatcher`.
 //
 // Returns:
 //   - newBot: The created bot instance.
@@ -56,17 +88,24 @@ var _ Bot = (*bot)(nil)
 //	    log.Fatalf("error creating bot: %v", err)
 //	}
 //	<-stopChan
-func NewBot(version, token string, intents []structs.Intent) (newBot Bot, stopChan chan struct{}, err error) {
+func NewBot(version, token string, intents []structs.Intent, opts ...Option) (newBot Bot, stopChan chan struct{}, err error) {
 	b := &bot{
 		mu:       &sync.Mutex{},
 		sessions: make(map[int]session.ClientSession),
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.cache == nil {
+		b.cache = cache.New(b.cacheOpts)
+	}
 
 	initialSession := session.NewClientSession(version)
 	initialSession.SetToken(token)
 	initialSession.SetIntents(intents...)
 	initialSession.SetShard(0)
 	initialSession.SetCb(b.reconnectCb)
+	b.configureSession(initialSession)
 	if err := initialSession.Dial(true); err != nil {
 		return nil, nil, err
 	}
@@ -90,6 +129,7 @@ func NewBot(version, token string, intents []structs.Intent) (newBot Bot, stopCh
 		sess.SetShard(shardID)
 		sess.SetShards(shards)
 		sess.SetCb(b.reconnectCb)
+		b.configureSession(sess)
 		if err := sess.Dial(false); err != nil {
 			return nil, nil, err
 		}
@@ -175,17 +215,14 @@ func (b *bot) GetSession(shardID int) (session.ClientSession, error) {
 //	    log.Fatalf("session not found for guild ID")
 //	}
 func (b *bot) GetSessionByGuildID(guildID structs.Snowflake) session.ClientSession {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	for _, session := range b.sessions {
-
-		if session.GetServerByGuildID(guildID) != nil {
-			return session
-		}
+	shard, ok, err := b.cache.GetGuildShard(guildID)
+	if err != nil || !ok {
+		return nil
 	}
 
-	return nil
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sessions[shard]
 }
 
 // RegisterCommands allows you to register any number of custom named handlers.
@@ -205,7 +242,7 @@ func (b *bot) GetSessionByGuildID(guildID structs.Snowflake) session.ClientSessi
 //		if interactionEvent, ok := payload.Data.(receiveevents.InteractionCreateEvent); ok {
 //			response := structs.NewInteractionResponseOptions()
 //			response.SetResponseType(structs.ChannelMessageWithSourceInteraction)
-//			response.SetFlags(structs.Bitfield[structs.MessageFlag]{structs.SurpressNotificationsMessageFlag})
+//			response.SetFlags(structs.NewBitfield(structs.SurpressNotificationsMessageFlag))
 //			response.SetContent("Pong!")
 //			if err := sess.InteractionReply(response, interactionEvent.Interaction); err != nil {
 //				return fmt.Errorf("could not reply to interaction: %v", err)
@@ -246,6 +283,18 @@ func (b *bot) RegisterCommands(commands map[string]session.CommandFunc) {
 //	}
 //	bot.RegisterListeners(listeners)
 //
+// The update and delete events of channels, threads, members, roles and messages carry the cached entity they replace
+// in their `Old` field, read from the cache when the event is received, before it is queued to be handled. An earlier event
+// for the same entity still waiting in the dispatcher isn't reflected yet. `Old` is nil when the entity wasn't cached:
+//
+//	session.GuildMemberUpdateListener: func(sess session.ClientSession, p payload.SessionPayload) error {
+//	    event := p.Data.(receiveevents.GuildMemberUpdateEvent)
+//	    if event.Old != nil && event.Old.Nickname != nil && event.Nick != nil {
+//	        log.Printf("nickname changed from %s to %s", *event.Old.Nickname, *event.Nick)
+//	    }
+//	    return nil
+//	},
+//
 // The available listeners are in the following format:
 //   - HelloListener = "HELLO"
 //   - ReadyListener = "READY"
@@ -297,6 +346,385 @@ func (b *bot) RegisterListeners(listeners map[session.Listener]session.CommandFu
 	}
 }
 
+// Use registers middlewares for all sessions.
+// Middlewares wrap every listener, named handler and interaction command, so they are a good place for cross-cutting concerns
+// like logging, tracing, feature flags or blocking certain users. They run in the order they are registered.
+//
+// Parameters:
+//   - middlewares: The middlewares to append to the handler chain.
+//
+// Example:
+//
+//	bot.Use(
+//	    session.RecoveryMiddleware(),
+//	    session.SlowHandlerMiddleware(2*time.Second),
+//	    func(next session.CommandFunc) session.CommandFunc {
+//	        return func(sess session.ClientSession, p payload.SessionPayload) error {
+//	            if p.EventName != nil {
+//	                log.Printf("handling %s on shard %d", *p.EventName, *sess.GetShard())
+//	            }
+//	            return next(sess, p)
+//	        }
+//	    },
+//	)
+func (b *bot) Use(middlewares ...session.Middleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, session := range b.sessions {
+		session.Use(middlewares...)
+	}
+}
+
+// GetDispatcherStats returns a snapshot of the dispatcher queues for every shard, keyed by shard ID.
+// Shards without a dispatcher, see `WithDispatcher`, are left out.
+//
+// Returns:
+//   - map[int]session.DispatcherStats: The dispatcher stats of each shard.
+//
+// Example:
+//
+//	for shardID, stats := range bot.GetDispatcherStats() {
+//	    log.Printf("shard %d: %d queued, %d dropped", shardID, stats.QueueDepth, stats.Dropped)
+//	}
+func (b *bot) GetDispatcherStats() map[int]session.DispatcherStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make(map[int]session.DispatcherStats)
+	for shardID, sess := range b.sessions {
+		if s, ok := sess.GetDispatcherStats(); ok {
+			stats[shardID] = s
+		}
+	}
+	return stats
+}
+
+// OnError sets the callback for errors returned by, and panics recovered from, handlers on every session.
+// Panics in listeners, named handlers and commands are always recovered so a single handler can't crash the bot,
+// the callback is where you can report them, i.e to an error tracker or a logging channel.
+//
+// Parameters:
+//   - handler: The callback receiving the details of the error.
+//
+// Example:
+//
+//	bot.OnError(func(ctx session.ErrorContext) {
+//	    log.Printf("shard %d failed handling %s: %v", ctx.Shard, ctx.EventName, ctx.Err)
+//	    if ctx.Panic != nil {
+//	        log.Printf("%s", ctx.Stack)
+//	    }
+//	})
+func (b *bot) OnError(handler func(session.ErrorContext)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, session := range b.sessions {
+		session.OnError(handler)
+	}
+}
+
+// SetCommandRouter routes slash commands on every session through the given router.
+// The router resolves subcommand groups and subcommands into a single path, and `session.Handle` binds the command options into a struct.
+// Commands the router has no route for still fall through to the handlers registered with `RegisterCommands`.
+//
+// Parameters:
+//   - router: The router shared by all sessions.
+//
+// Example:
+//
+//	type greetOptions struct {
+//	    User  *structs.User `discord:"user,required"`
+//	    Shout bool          `discord:"shout"`
+//	}
+//
+//	router := session.NewCommandRouter()
+//	session.Handle(router, "greet user", func(ctx *session.CommandContext, opts greetOptions) error {
+//	    msg := "hello " + opts.User.Username
+//	    if opts.Shout {
+//	        msg = strings.ToUpper(msg)
+//	    }
+//	    return ctx.Reply(msg)
+//	})
+//	bot.SetCommandRouter(router)
+func (b *bot) SetCommandRouter(router *session.CommandRouter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.router = router
+	for _, session := range b.sessions {
+		session.SetCommandRouter(router)
+	}
+}
+
+// SyncCommands pushes the commands declared on the command router to Discord.
+// Only the differences are pushed: new commands are created, changed commands are updated, and commands that are
+// no longer declared are deleted, unless `KeepUnknown` is set. Use `DryRun` to print the plan without changing anything.
+//
+// Parameters:
+//   - opts: The options controlling how the changes are applied.
+//
+// Returns:
+//   - *session.SyncPlan: The changes that were made, or would be made in dry-run mode.
+//   - error: An error if the commands could not be compared or pushed.
+//
+// Example:
+//
+//	router := session.NewCommandRouter()
+//	router.Register(session.Command{
+//	    Name:        "ping",
+//	    Description: "Replies with pong",
+//	    Handler: func(ctx *session.CommandContext) error {
+//	        return ctx.Reply("pong")
+//	    },
+//	})
+//	bot.SetCommandRouter(router)
+//	if _, err := bot.SyncCommands(session.SyncOptions{}); err != nil {
+//	    log.Fatalf("error syncing commands: %v", err)
+//	}
+func (b *bot) SyncCommands(opts session.SyncOptions) (*session.SyncPlan, error) {
+	b.mu.Lock()
+	router := b.router
+	sess, ok := b.sessions[0]
+	b.mu.Unlock()
+
+	if router == nil {
+		return nil, fmt.Errorf("no command router set")
+	}
+	if !ok {
+		return nil, fmt.Errorf("no session found for shard ID 0")
+	}
+	return router.Sync(sess, opts)
+}
+
+// GetCache returns the cache store shared by every session of the bot, holding the guilds, channels, threads, members, roles,
+// emojis, voice states, presences and messages received from the gateway across all shards.
+//
+// Returns:
+//   - cache.CacheStore: The cache store of the bot, the in-memory `*cache.Cache` unless `bot.WithCacheStore` set another one.
+//     Getters return copies so it is safe to use from any goroutine.
+//
+// Example:
+//
+//	err := bot.GetCache().ScanRoles(guildID, func(role structs.Role) bool {
+//	    fmt.Println(role.Name)
+//	    return true
+//	})
+func (b *bot) GetCache() cache.CacheStore {
+	return b.cache
+}
+
+// GetCacheStats returns what the cache of the bot holds, and how much it evicted because of the options set with `bot.WithCache`.
+// Stores that don't keep stats return empty ones.
+//
+// Returns:
+//   - cache.Stats: The amount of cached entities, along with the eviction and filter counters.
+//
+// Example:
+//
+//	stats := bot.GetCacheStats()
+//	log.Printf("%d messages cached, %d evicted", stats.Messages, stats.MessageEvictions)
+func (b *bot) GetCacheStats() cache.Stats {
+	if c, ok := b.cache.(interface{ Stats() cache.Stats }); ok {
+		return c.Stats()
+	}
+	return cache.Stats{}
+}
+
+// GetGuild returns the cached guild with its roles and emojis, whichever shard handles it.
+// If the guild is not cached, nil will be returned.
+//
+// Parameters:
+//   - guildID: The ID of the guild.
+//
+// Returns:
+//   - *structs.Guild: A copy of the cached guild.
+//   - error: An error if the cache store failed.
+//
+// Example:
+//
+//	guild, err := bot.GetGuild(guildID)
+//	if err == nil && guild == nil {
+//	    log.Println("guild not cached")
+//	}
+func (b *bot) GetGuild(guildID structs.Snowflake) (*structs.Guild, error) {
+	return b.cache.GetGuild(guildID)
+}
+
+// GetChannel returns the cached channel or thread, whichever shard handles its guild.
+// If the channel is not cached, nil will be returned.
+//
+// Parameters:
+//   - channelID: The ID of the channel or thread.
+//
+// Returns:
+//   - *structs.Channel: A copy of the cached channel.
+//   - error: An error if the cache store failed.
+//
+// Example:
+//
+//	channel, err := bot.GetChannel(channelID)
+//	if err == nil && channel != nil && channel.Name != nil {
+//	    fmt.Println(*channel.Name)
+//	}
+func (b *bot) GetChannel(channelID structs.Snowflake) (*structs.Channel, error) {
+	return b.cache.GetChannel(channelID)
+}
+
+// GetMember returns the cached member of the guild, whichever shard handles it.
+// If the member is not cached, nil will be returned.
+//
+// Parameters:
+//   - guildID: The ID of the guild.
+//   - userID: The ID of the user.
+//
+// Returns:
+//   - *structs.GuildMember: A copy of the cached member.
+//   - error: An error if the cache store failed.
+//
+// Example:
+//
+//	member, err := bot.GetMember(guildID, userID)
+//	if err == nil && member != nil && member.Nickname != nil {
+//	    fmt.Println(*member.Nickname)
+//	}
+func (b *bot) GetMember(guildID, userID structs.Snowflake) (*structs.GuildMember, error) {
+	return b.cache.GetMember(guildID, userID)
+}
+
+// GetSelfPermissions returns the permissions of the bot in a guild channel or thread, computed from the cache
+// with `structs.ComputePermissions`. Threads take the overwrites of their parent channel.
+//
+// Parameters:
+//   - channelID: The ID of the channel or thread.
+//
+// Returns:
+//   - structs.Permission: The permissions of the bot in the channel.
+//   - error: cache.ErrNotCached if the channel, its guild or the member of the bot is not cached.
+//
+// Example:
+//
+//	permissions, err := bot.GetSelfPermissions(channelID)
+//	if err == nil && permissions.Has(structs.ManageMessages) {
+//	    // delete the message
+//	}
+func (b *bot) GetSelfPermissions(channelID structs.Snowflake) (structs.Permission, error) {
+	sess, err := b.GetSession(0)
+	if err != nil {
+		return 0, err
+	}
+	return sess.SelfPermissions(channelID)
+}
+
+// CanSend reports whether the bot can send messages in the channel or thread.
+// If the permissions can't be computed from the cache, false will be returned.
+//
+// Parameters:
+//   - channelID: The ID of the channel or thread.
+//
+// Returns:
+//   - bool: True if the bot can view the channel and send messages in it.
+//
+// Example:
+//
+//	if !bot.CanSend(channelID) {
+//	    log.Println("missing permissions to send messages")
+//	}
+func (b *bot) CanSend(channelID structs.Snowflake) bool {
+	// the cache is shared, so every session computes the same permissions
+	sess, err := b.GetSession(0)
+	if err != nil {
+		return false
+	}
+	return sess.CanSend(channelID)
+}
+
+// RequestGuildMembers asks the gateway for members of the guild and waits for every chunk of the answer, see `session.ClientSession.RequestGuildMembers`.
+// The request is sent on the shard handling the guild.
+//
+// Parameters:
+//   - ctx: Cancels the wait for the chunks, the request times out after 30 seconds when it has no deadline.
+//   - guildID: The ID of the guild.
+//   - request: The members to request, a zero value requests every member.
+//
+// Returns:
+//   - *session.GuildMembersResult: Every member Discord returned.
+//   - error: An error if the guild isn't handled by any shard, or if the request failed or timed out.
+//
+// Example:
+//
+//	result, err := bot.RequestGuildMembers(ctx, guildID, session.GuildMembersRequest{Query: "car", Limit: 10})
+//	if err != nil {
+//	    log.Println(err)
+//	}
+func (b *bot) RequestGuildMembers(ctx context.Context, guildID structs.Snowflake, request session.GuildMembersRequest) (*session.GuildMembersResult, error) {
+	sess := b.GetSessionByGuildID(guildID)
+	if sess == nil {
+		return nil, errors.New("no session found for the guild")
+	}
+	return sess.RequestGuildMembers(ctx, guildID, request)
+}
+
+// SetPresence updates the status and activities of the bot on every shard, see `session.ClientSession.SetPresence`.
+// Every shard is updated even if one of them fails.
+//
+// Parameters:
+//   - status: The status of the bot, an empty status is online.
+//   - activities: The activities to show.
+//   - afk: Whether the bot is away from keyboard.
+//
+// Returns:
+//   - error: The errors of the shards that couldn't be updated, joined.
+//
+// Example:
+//
+//	err := bot.SetPresence(structs.UserOnline, []structs.Activity{{
+//	    Name: "with the API",
+//	    Type: structs.GameActivity,
+//	}}, false)
+func (b *bot) SetPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error {
+	b.mu.Lock()
+	sessions := make([]session.ClientSession, 0, len(b.sessions))
+	for _, sess := range b.sessions {
+		sessions = append(sessions, sess)
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, sess := range sessions {
+		if err := sess.SetPresence(status, activities, afk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Latency returns the average heartbeat round trip of the shards, the shards that haven't received an ACK yet are left out.
+//
+// Returns:
+//   - time.Duration: The average latency, 0 if no shard measured one yet.
+//
+// Example:
+//
+//	fmt.Printf("Pong! %dms\n", bot.Latency().Milliseconds())
+func (b *bot) Latency() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var total time.Duration
+	var count int
+	for _, sess := range b.sessions {
+		if latency := sess.Latency(); latency > 0 {
+			total += latency
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
+
 func (b *bot) run(stopChan chan struct{}) error {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
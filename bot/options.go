@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"log"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/session"
+)
+
+// Option configures the bot, and every session it creates, when passed to `NewBot`.
+type Option func(b *bot)
+
+// WithDispatcher routes gateway dispatch events through a bounded worker pool on every shard,
+// instead of handling each event in its own goroutine.
+//
+// Parameters:
+//   - opts: The dispatcher configuration, zero values fall back to sensible defaults.
+//
+// Example:
+//
+//	bot, stopChan, err := bot.NewBot("", token, intents, bot.WithDispatcher(session.DispatcherOptions{
+//	    Workers:  8,
+//	    Ordering: session.GuildOrdering,
+//	    Overflow: session.OverflowSpill,
+//	}))
+func WithDispatcher(opts session.DispatcherOptions) Option {
+	return func(b *bot) {
+		b.dispatcherOpts = &opts
+	}
+}
+
+// WithCache configures what the cache shared by every shard keeps, by default everything is cached without limits.
+//
+// Parameters:
+//   - opts: The cache options, zero values keep the default behavior.
+//
+// Example:
+//
+//	bot, stopChan, err := bot.NewBot("", token, intents, bot.WithCache(cache.Options{
+//	    DisablePresences: true,
+//	    MaxMessages:      100,
+//	    MemberTTL:        time.Hour,
+//	    MemberFilter: func(guildID structs.Snowflake, member structs.GuildMember) bool {
+//	        return len(member.Roles) > 0
+//	    },
+//	}))
+func WithCache(opts cache.Options) Option {
+	return func(b *bot) {
+		b.cacheOpts = opts
+	}
+}
+
+// WithCacheStore replaces the in-memory cache shared by every shard with the store, i.e one shared by the processes of a cluster.
+// The options of `bot.WithCache` don't apply to it, the store decides what it keeps.
+//
+// Parameters:
+//   - store: The cache store the handlers write the gateway updates through.
+//
+// Example:
+//
+//	store, err := cache.NewFileStore("state.json", cache.Options{}, time.Minute)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer store.Close()
+//	bot, stopChan, err := bot.NewBot("", token, intents, bot.WithCacheStore(store))
+func WithCacheStore(store cache.CacheStore) Option {
+	return func(b *bot) {
+		b.cache = store
+	}
+}
+
+// WithGuildChunking requests every member of the large guilds once they become available after READY,
+// so the cache holds all of their members instead of only the online ones. This needs the GUILD_MEMBERS intent.
+//
+// Example:
+//
+//	bot, stopChan, err := bot.NewBot("", token, []structs.Intent{structs.GuildsIntent, structs.GuildMembersIntent}, bot.WithGuildChunking())
+func WithGuildChunking() Option {
+	return func(b *bot) {
+		b.chunkGuilds = true
+	}
+}
+
+// WithPresence sets the presence every shard identifies with, so the bot comes online with it.
+// Use `Bot.SetPresence` to change it afterwards.
+//
+// Parameters:
+//   - status: The status of the bot, an empty status is online.
+//   - activities: The activities to show.
+//
+// Example:
+//
+//	bot, stopChan, err := bot.NewBot("", token, intents, bot.WithPresence(structs.UserIdle,
+//	    structs.Activity{Name: "/help", Type: structs.ListeningActivity},
+//	))
+func WithPresence(status structs.UserStatusType, activities ...structs.Activity) Option {
+	return func(b *bot) {
+		b.presence = &initialPresence{status: status, activities: activities}
+	}
+}
+
+// configureSession applies the bot options to a session, this has to happen before the session dials the gateway.
+func (b *bot) configureSession(sess session.ClientSession) {
+	sess.SetCache(b.cache)
+	if b.dispatcherOpts != nil {
+		sess.SetDispatcher(*b.dispatcherOpts)
+	}
+	if b.chunkGuilds {
+		sess.SetChunkGuilds(true)
+	}
+	if b.presence != nil {
+		if err := sess.SetInitialPresence(b.presence.status, b.presence.activities, false); err != nil {
+			log.Printf("ignoring the initial presence: %v", err)
+		}
+	}
+}
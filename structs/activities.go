@@ -27,6 +27,23 @@ const (
 	ActivityFlagEmbedded            ActivityFlag = 1 << 8
 )
 
+var activityFlagNames = map[ActivityFlag]string{
+	ActivityFlagInstance:            "INSTANCE",
+	ActivityFlagJoin:                "JOIN",
+	ActivityFlagSpectate:            "SPECTATE",
+	ActivityFlagJoinRequest:         "JOIN_REQUEST",
+	ActivityFlagSync:                "SYNC",
+	ActivityFlagPlay:                "PLAY",
+	ActivityFlagPartyPrivacyFriends: "PARTY_PRIVACY_FRIENDS",
+	ActivityFlagPartyPrivacyVoice:   "PARTY_PRIVACY_VOICE_CHANNEL",
+	ActivityFlagEmbedded:            "EMBEDDED",
+}
+
+// String returns the Discord names of the set flags.
+func (f ActivityFlag) String() string {
+	return flagNames(f, activityFlagNames)
+}
+
 type Activity struct {
 	Name          string                  `json:"name"`
 	Type          ActivityType            `json:"type"`
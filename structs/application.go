@@ -57,6 +57,24 @@ const (
 	ApplicationCommandBadge                    ApplicationFlag = 1 << 23
 )
 
+var applicationFlagNames = map[ApplicationFlag]string{
+	ApplicationAutoModerationOnRuleCreateBadge: "APPLICATION_AUTO_MODERATION_RULE_CREATE_BADGE",
+	GatewayPresence:               "GATEWAY_PRESENCE",
+	GatewayPresenceLimited:        "GATEWAY_PRESENCE_LIMITED",
+	GatewayGuildMembers:           "GATEWAY_GUILD_MEMBERS",
+	GatewayGuildMembersLimited:    "GATEWAY_GUILD_MEMBERS_LIMITED",
+	VerificationPendingGuildLimit: "VERIFICATION_PENDING_GUILD_LIMIT",
+	EmbeddedApplicationFlag:       "EMBEDDED",
+	GatewayMessageContent:         "GATEWAY_MESSAGE_CONTENT",
+	GatewayMessageContentLimited:  "GATEWAY_MESSAGE_CONTENT_LIMITED",
+	ApplicationCommandBadge:       "APPLICATION_COMMAND_BADGE",
+}
+
+// String returns the Discord names of the set flags.
+func (f ApplicationFlag) String() string {
+	return flagNames(f, applicationFlagNames)
+}
+
 type InstallParams struct {
 	Scopes      []OAuth2Scope        `json:"scopes"`
 	Permissions Bitfield[Permission] `json:"permissions"`
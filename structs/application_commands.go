@@ -40,11 +40,11 @@ type ApplicationCommandOption struct {
 	Choices                  []ApplicationCommandOptionChoice `json:"choices,omitempty"`
 	Options                  []ApplicationCommandOption       `json:"options,omitempty"`
 	ChannelTypes             []ChannelType                    `json:"channel_types,omitempty"`
-	MinValue                 float64                          `json:"min_value"`
-	MaxValue                 float64                          `json:"max_value"`
-	MinLength                int                              `json:"min_length"`
-	MaxLength                int                              `json:"max_length"`
-	Autocomplete             bool                             `json:"autocomplete"`
+	MinValue                 *float64                         `json:"min_value,omitempty"`
+	MaxValue                 *float64                         `json:"max_value,omitempty"`
+	MinLength                *int                             `json:"min_length,omitempty"`
+	MaxLength                *int                             `json:"max_length,omitempty"`
+	Autocomplete             bool                             `json:"autocomplete,omitempty"`
 }
 
 type ApplicationCommandOptionType int
@@ -2,135 +2,173 @@ package structs
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
 )
 
-type Bitfield[T any] []T
+// Flag is implemented by the flag types a Bitfield holds, i.e `Permission` or `MessageFlag`.
+type Flag interface {
+	~int64
+}
 
-func (b *Bitfield[T]) UnmarshalJSON(data []byte) error {
-	var flags int64
-	if err := json.Unmarshal(data, &flags); err != nil {
-		return err
-	}
+// Bitfield is a set of flags of type T, stored as the integer Discord sends.
+// The zero value is the empty set, and bitfields compare with ==.
+//
+// Bitfields of permissions are encoded as JSON strings, as Discord does since they exceed the 53 bits a JSON number can hold exactly.
+// Other bitfields are encoded as JSON numbers, both encodings are accepted when decoding.
+type Bitfield[T Flag] int64
 
-	parsedFlags, err := convert[T](flags)
-	if err != nil {
-		return err
-	}
+// NewBitfield returns a bitfield holding the flags.
+//
+// Example:
+//
+//	flags := structs.NewBitfield(structs.EphemeralMessageFlag, structs.SurpressEmbedsMessageFlag)
+func NewBitfield[T Flag](flags ...T) Bitfield[T] {
+	var b Bitfield[T]
+	b.Add(flags...)
+	return b
+}
 
-	*b = b.ParseFlags(parsedFlags)
-	return nil
+// Value returns the flags combined in a single T.
+func (b Bitfield[T]) Value() T {
+	return T(b)
 }
 
-func (b *Bitfield[T]) MarshalJSON() ([]byte, error) {
-	flags := b.GetFlags()
-	return json.Marshal(flags)
+// Has reports whether every flag is set.
+func (b Bitfield[T]) Has(flags ...T) bool {
+	mask := combine(flags)
+	return b&mask == mask
 }
 
-func (b *Bitfield[T]) GetFlags() int64 {
-	var flag int64
-	for _, i := range *b {
-		v, err := toInt64(i)
-		if err != nil {
-			return 0
-		}
-		flag |= v
+// HasAny reports whether at least one of the flags is set.
+func (b Bitfield[T]) HasAny(flags ...T) bool {
+	return b&combine(flags) != 0
+}
+
+// IsEmpty reports whether no flag is set.
+func (b Bitfield[T]) IsEmpty() bool {
+	return b == 0
+}
+
+// Add sets the flags.
+func (b *Bitfield[T]) Add(flags ...T) {
+	*b |= combine(flags)
+}
+
+// Remove clears the flags.
+func (b *Bitfield[T]) Remove(flags ...T) {
+	*b &^= combine(flags)
+}
+
+// Toggle sets the flags that are clear, and clears the ones that are set.
+func (b *Bitfield[T]) Toggle(flags ...T) {
+	*b ^= combine(flags)
+}
+
+// Union returns the flags set in either bitfield.
+func (b Bitfield[T]) Union(other Bitfield[T]) Bitfield[T] {
+	return b | other
+}
+
+// Intersection returns the flags set in both bitfields.
+func (b Bitfield[T]) Intersection(other Bitfield[T]) Bitfield[T] {
+	return b & other
+}
+
+// Difference returns the flags set in b but not in other.
+func (b Bitfield[T]) Difference(other Bitfield[T]) Bitfield[T] {
+	return b &^ other
+}
+
+// Flags returns every set flag on its own, lowest bit first.
+//
+// Example:
+//
+//	for _, flag := range message.Flags.Flags() {
+//	    fmt.Println(flag)
+//	}
+func (b Bitfield[T]) Flags() []T {
+	flags := make([]T, 0, bits.OnesCount64(uint64(b)))
+	for rest := uint64(b); rest != 0; rest &= rest - 1 {
+		flags = append(flags, T(1)<<bits.TrailingZeros64(rest))
 	}
-	return flag
+	return flags
 }
 
-func (b *Bitfield[T]) ParseFlags(flags T) []T {
-	var parsedFlags []T
-	flagValue := any(flags).(T)
-	for _, f := range *b {
-		if any(f).(int64)&any(flagValue).(int64) != 0 {
-			parsedFlags = append(parsedFlags, f)
-		}
+// Names returns the name of every set flag, lowest bit first. Flags without a name are written as `1<<bit`.
+func (b Bitfield[T]) Names() []string {
+	var names []string
+	for _, flag := range b.Flags() {
+		names = append(names, flagName(flag))
 	}
-	return parsedFlags
+	return names
 }
 
-func (b *Bitfield[T]) SetFlags(flags T) {
-	flagsVal := b.ParseFlags(flags)
-	*b = any(flagsVal).([]T)
+// String returns the names of the set flags, for logging.
+func (b Bitfield[T]) String() string {
+	if b == 0 {
+		return "none"
+	}
+	return strings.Join(b.Names(), ", ")
 }
 
-func (b *Bitfield[T]) AddFlag(flag T) {
-	*b = append(*b, any(flag).(T))
+// ToString returns the integer of the bitfield in base 10, as Discord expects it in query strings.
+func (b Bitfield[T]) ToString() string {
+	return strconv.FormatInt(int64(b), 10)
 }
 
-func (b *Bitfield[T]) RemoveFlag(flag T) {
-	for i, f := range *b {
-		if any(f).(int64) == any(flag).(int64) {
-			*b = append((*b)[:i], (*b)[i+1:]...)
-		}
+func (b Bitfield[T]) MarshalJSON() ([]byte, error) {
+	if _, ok := any(T(0)).(Permission); ok {
+		return json.Marshal(b.ToString())
+	}
+	return json.Marshal(int64(b))
+}
+
+func (b *Bitfield[T]) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return errors.New("invalid bitfield: " + string(data))
 	}
+	*b = Bitfield[T](value)
+	return nil
 }
 
-func (b *Bitfield[T]) ToString() string {
-	var result string
-	for _, f := range *b {
-		result += fmt.Sprintf("%d", any(f).(int64))
+func combine[T Flag](flags []T) Bitfield[T] {
+	var mask Bitfield[T]
+	for _, flag := range flags {
+		mask |= Bitfield[T](flag)
 	}
-	return result
-}
-
-// convert converts an int64 to type T
-func convert[T any](value int64) (T, error) {
-	var t T
-	switch any(t).(type) {
-	case ActivityFlag:
-		return any(ActivityFlag(value)).(T), nil
-	case ApplicationFlag:
-		return any(ApplicationFlag(value)).(T), nil
-	case ChannelFlag:
-		return any(ChannelFlag(value)).(T), nil
-	case GuildMemberFlag:
-		return any(GuildMemberFlag(value)).(T), nil
-	case SystemChannelFlag:
-		return any(SystemChannelFlag(value)).(T), nil
-	case AttachmentFlag:
-		return any(AttachmentFlag(value)).(T), nil
-	case MessageFlag:
-		return any(MessageFlag(value)).(T), nil
-	case RoleFlag:
-		return any(RoleFlag(value)).(T), nil
-	case UserFlag:
-		return any(UserFlag(value)).(T), nil
-	case Permission:
-		return any(Permission(value)).(T), nil
-	case SpeakingFlag:
-		return any(SpeakingFlag(value)).(T), nil
-	default:
-		return t, fmt.Errorf("unsupported type conversion from int64 to %T", t)
+	return mask
+}
+
+// flagName returns the name of a single bit flag, from its String method when it has one.
+func flagName[T Flag](flag T) string {
+	if stringer, ok := any(flag).(fmt.Stringer); ok {
+		if name := stringer.String(); name != "" {
+			return name
+		}
 	}
+	return fmt.Sprintf("1<<%d", bits.TrailingZeros64(uint64(flag)))
 }
 
-func toInt64[T any](value T) (int64, error) {
-	switch v := any(value).(type) {
-	case ActivityFlag:
-		return int64(v), nil
-	case ApplicationFlag:
-		return int64(v), nil
-	case ChannelFlag:
-		return int64(v), nil
-	case GuildMemberFlag:
-		return int64(v), nil
-	case SystemChannelFlag:
-		return int64(v), nil
-	case AttachmentFlag:
-		return int64(v), nil
-	case MessageFlag:
-		return int64(v), nil
-	case RoleFlag:
-		return int64(v), nil
-	case UserFlag:
-		return int64(v), nil
-	case Permission:
-		return int64(v), nil
-	case SpeakingFlag:
-		return int64(v), nil
-	default:
-		return 0, fmt.Errorf("unsupported type conversion from %T to int64", value)
+// flagNames returns the names of the flags set in value, used by the String methods of the flag types.
+func flagNames[T Flag](value T, names map[T]string) string {
+	var set []string
+	for _, flag := range Bitfield[T](value).Flags() {
+		if name, ok := names[flag]; ok {
+			set = append(set, name)
+		}
 	}
+	return strings.Join(set, ", ")
 }
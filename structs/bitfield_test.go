@@ -0,0 +1,154 @@
+package structs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBitfieldMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "permissions are strings", value: NewBitfield(SendMessages, ViewChannel), want: `"3072"`},
+		{name: "permissions above 53 bits", value: Bitfield[Permission](AllPermissions), want: `"9223372036854775807"`},
+		{name: "empty permissions", value: Bitfield[Permission](0), want: `"0"`},
+		{name: "other flags are numbers", value: NewBitfield(EphemeralMessageFlag), want: `64`},
+		{
+			name: "in a struct",
+			value: struct {
+				Allow Bitfield[Permission]  `json:"allow"`
+				Flags Bitfield[MessageFlag] `json:"flags"`
+			}{Allow: NewBitfield(SendMessages), Flags: NewBitfield(EphemeralMessageFlag)},
+			want: `{"allow":"2048","flags":64}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("json.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitfieldUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    Bitfield[Permission]
+		wantErr bool
+	}{
+		{data: `"3072"`, want: NewBitfield(SendMessages, ViewChannel)},
+		{data: `3072`, want: NewBitfield(SendMessages, ViewChannel)},
+		{data: `"9223372036854775807"`, want: Bitfield[Permission](AllPermissions)},
+		{data: `null`, want: NewBitfield(KickMembers)},
+		{data: `"many"`, wantErr: true},
+		{data: `1.5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			// null leaves the value as it was
+			got := NewBitfield(KickMembers)
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("json.Unmarshal() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("json.Unmarshal() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitfieldRoundTrip(t *testing.T) {
+	role := Role{ID: *NewSnowflake(1), Permissions: NewBitfield(Administrator, UseExternalApps), Flags: NewBitfield(RoleFlag(1))}
+	data, err := json.Marshal(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Role
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Permissions != role.Permissions || decoded.Flags != role.Flags {
+		t.Fatalf("decoded %v and %v, want %v and %v", decoded.Permissions, decoded.Flags, role.Permissions, role.Flags)
+	}
+}
+
+func TestBitfieldOperations(t *testing.T) {
+	b := NewBitfield(SendMessages, EmbedLinks)
+	b.Add(AddReactions)
+	b.Remove(EmbedLinks)
+	b.Toggle(SendMessages, ViewChannel)
+
+	if want := NewBitfield(AddReactions, ViewChannel); b != want {
+		t.Fatalf("bitfield = %v, want %v", b, want)
+	}
+	if !b.Has(AddReactions, ViewChannel) || b.Has(AddReactions, SendMessages) {
+		t.Fatal("Has() doesn't require every flag")
+	}
+	if !b.HasAny(SendMessages, ViewChannel) || b.HasAny(SendMessages, EmbedLinks) {
+		t.Fatal("HasAny() doesn't accept any flag")
+	}
+
+	other := NewBitfield(ViewChannel, SendMessages)
+	if got, want := b.Union(other), NewBitfield(AddReactions, ViewChannel, SendMessages); got != want {
+		t.Fatalf("Union() = %v, want %v", got, want)
+	}
+	if got, want := b.Intersection(other), NewBitfield(ViewChannel); got != want {
+		t.Fatalf("Intersection() = %v, want %v", got, want)
+	}
+	if got, want := b.Difference(other), NewBitfield(AddReactions); got != want {
+		t.Fatalf("Difference() = %v, want %v", got, want)
+	}
+}
+
+func TestBitfieldNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		stringed string
+		bitfield interface {
+			Names() []string
+			String() string
+		}
+	}{
+		{
+			name:     "permissions",
+			bitfield: NewBitfield(ManageMessages, KickMembers),
+			names:    []string{"KICK_MEMBERS", "MANAGE_MESSAGES"},
+			stringed: "KICK_MEMBERS, MANAGE_MESSAGES",
+		},
+		{
+			name:     "unnamed bit",
+			bitfield: NewBitfield(EphemeralMessageFlag, MessageFlag(1<<20)),
+			names:    []string{"EPHEMERAL", "1<<20"},
+			stringed: "EPHEMERAL, 1<<20",
+		},
+		{name: "empty", bitfield: Bitfield[Permission](0), stringed: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.bitfield.Names(); !reflect.DeepEqual(got, tt.names) {
+				t.Fatalf("Names() = %q, want %q", got, tt.names)
+			}
+			if got := tt.bitfield.String(); got != tt.stringed {
+				t.Fatalf("String() = %q, want %q", got, tt.stringed)
+			}
+		})
+	}
+
+	if got, want := (ManageMessages | KickMembers).Names(), []string{"KICK_MEMBERS", "MANAGE_MESSAGES"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Permission.Names() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,308 @@
+package cache
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// Cache holds the state received from the gateway in maps keyed by snowflake, so lookups don't scan.
+// A single cache is shared by every shard of a bot, which makes lookups across shards possible.
+//
+// Every accessor returns a copy, the slices handlers usually edit (roles, overwrites, reactions, poll answers) included,
+// so changing what is returned never changes the cache. Write the change back with the matching `Set` method instead.
+// A cache is safe for concurrent use.
+type Cache struct {
+	mu *sync.RWMutex
+
+	opts      Options
+	stats     *cacheStats
+	selfID    string
+	lastSweep time.Time
+
+	guilds map[string]*guildEntry
+	// every cached channel and thread, guild channels are also indexed on their guild
+	channels map[string]structs.Channel
+	// messages by channel ID
+	messages map[string]*messageBucket
+}
+
+type guildEntry struct {
+	guild structs.Guild
+	shard int
+
+	joinedAt             time.Time
+	large                bool
+	unavailable          *bool
+	memberCount          int
+	stageInstances       []structs.StageInstance
+	guildScheduledEvents []structs.GuildScheduledEvent
+
+	channels    map[string]struct{}
+	threads     map[string]struct{}
+	members     map[string]structs.GuildMember
+	memberSeen  map[string]time.Time
+	roles       map[string]structs.Role
+	emojis      map[string]structs.Emoji
+	voiceStates map[string]structs.VoiceState
+	presences   map[string]structs.PresenceUpdate
+}
+
+// New creates an empty cache, the options decide what it keeps, see `Options`.
+func New(opts Options) *Cache {
+	return &Cache{
+		mu:        &sync.RWMutex{},
+		opts:      opts,
+		stats:     &cacheStats{},
+		lastSweep: time.Now(),
+		guilds:    make(map[string]*guildEntry),
+		channels:  make(map[string]structs.Channel),
+		messages:  make(map[string]*messageBucket),
+	}
+}
+
+var _ CacheStore = (*Cache)(nil)
+
+func newGuildEntry(shard int, guild structs.Guild) *guildEntry {
+	return &guildEntry{
+		guild:       guild,
+		shard:       shard,
+		channels:    make(map[string]struct{}),
+		threads:     make(map[string]struct{}),
+		members:     make(map[string]structs.GuildMember),
+		memberSeen:  make(map[string]time.Time),
+		roles:       make(map[string]structs.Role),
+		emojis:      make(map[string]structs.Emoji),
+		voiceStates: make(map[string]structs.VoiceState),
+		presences:   make(map[string]structs.PresenceUpdate),
+	}
+}
+
+// SetServer caches a guild and everything the `GUILD_CREATE` event carries with it, replacing what was cached for the guild.
+//
+// Parameters:
+//   - shard: The shard receiving the events of the guild.
+//   - server: The guild, with its channels, threads, members, roles, emojis, voice states and presences.
+//
+// Returns:
+//   - error: Always nil, the cache lives in memory.
+func (c *Cache) SetServer(shard int, server structs.Server) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if server.Guild == nil {
+		return nil
+	}
+	c.deleteGuild(server.ID.ToString())
+
+	entry := newGuildEntry(shard, cloneGuild(*server.Guild))
+	entry.joinedAt = server.JoinedAt
+	entry.large = server.Large
+	entry.unavailable = server.Unavailable
+	entry.memberCount = server.MemberCount
+	entry.stageInstances = slices.Clone(server.StageInstances)
+	entry.guildScheduledEvents = slices.Clone(server.GuildScheduledEvents)
+	entry.setRoles(server.Roles)
+	entry.setEmojis(server.Emojis)
+	entry.guild.Roles, entry.guild.Emojis = nil, nil
+
+	guildID := server.ID.ToString()
+	c.guilds[guildID] = entry
+
+	for _, channel := range server.Channels {
+		c.setChannel(withGuild(channel, server.ID))
+	}
+	for _, thread := range server.Threads {
+		c.setChannel(withGuild(thread, server.ID))
+	}
+	for _, member := range server.Members {
+		if c.allowMember(server.ID, member) {
+			c.setMember(entry, member)
+		}
+	}
+	for _, voiceState := range server.VoiceStates {
+		if voiceState.ChannelID != nil && !c.opts.DisableVoiceStates {
+			entry.voiceStates[voiceState.UserID.ToString()] = cloneVoiceState(voiceState)
+		}
+	}
+	for _, presence := range server.Presences {
+		if c.allowPresence(server.ID, presence) {
+			entry.presences[presence.User.ID.ToString()] = clonePresence(presence)
+		}
+	}
+	return nil
+}
+
+// GetServer returns a snapshot of the guild with everything cached for it, nil if the guild isn't cached.
+// The messages of each channel are included, building the snapshot is costly for big guilds so prefer the
+// accessors of the entities needed.
+func (c *Cache) GetServer(guildID structs.Snowflake) (*structs.Server, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	return c.server(entry), nil
+}
+
+// server builds the snapshot of a guild entry. Must be called with the lock held.
+func (c *Cache) server(entry *guildEntry) *structs.Server {
+	guild := entry.snapshot()
+	server := structs.NewServer(&guild)
+	server.JoinedAt = entry.joinedAt
+	server.Large = entry.large
+	server.Unavailable = entry.unavailable
+	server.MemberCount = entry.memberCount
+	server.StageInstances = slices.Clone(entry.stageInstances)
+	server.GuildScheduledEvents = slices.Clone(entry.guildScheduledEvents)
+
+	for _, id := range sortedKeys(entry.channels) {
+		channel := cloneChannel(c.channels[id])
+		channel.Messages = c.channelMessages(id)
+		server.Channels = append(server.Channels, channel)
+	}
+	for _, id := range sortedKeys(entry.threads) {
+		thread := cloneChannel(c.channels[id])
+		thread.Messages = c.channelMessages(id)
+		server.Threads = append(server.Threads, thread)
+	}
+	server.Members = c.memberList(entry)
+	server.VoiceStates = sortedValues(entry.voiceStates, cloneVoiceState)
+	server.Presences = sortedValues(entry.presences, clonePresence)
+	return server
+}
+
+// SetGuild updates a cached guild, keeping its channels, members and the other entities cached with it.
+// The roles and emojis of the guild replace the cached ones when set, as they are with `GUILD_UPDATE`.
+// A guild that isn't cached yet is added.
+func (c *Cache) SetGuild(shard int, guild structs.Guild) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.guilds[guild.ID.ToString()]
+	if !ok {
+		entry = newGuildEntry(shard, guild)
+		c.guilds[guild.ID.ToString()] = entry
+	}
+	entry.shard = shard
+
+	if guild.Roles != nil {
+		entry.setRoles(guild.Roles)
+	}
+	if guild.Emojis != nil {
+		entry.setEmojis(guild.Emojis)
+	}
+	guild.Roles, guild.Emojis = nil, nil
+	entry.guild = cloneGuild(guild)
+	return nil
+}
+
+// GetGuild returns the guild with its roles and emojis, nil if it isn't cached.
+func (c *Cache) GetGuild(guildID structs.Snowflake) (*structs.Guild, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	guild := entry.snapshot()
+	return &guild, nil
+}
+
+// GetGuildShard returns the shard handling the events of the guild.
+func (c *Cache) GetGuildShard(guildID structs.Snowflake) (int, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return 0, false, nil
+	}
+	return entry.shard, true, nil
+}
+
+// DeleteGuild removes the guild and everything cached with it, its channels and their messages included.
+func (c *Cache) DeleteGuild(guildID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteGuild(guildID.ToString())
+	return nil
+}
+
+// ScanGuilds calls fn for every cached guild, sorted by ID, until it returns false.
+func (c *Cache) ScanGuilds(fn func(shard int, guild structs.Guild) bool) error {
+	type shardGuild struct {
+		shard int
+		guild structs.Guild
+	}
+
+	c.mu.RLock()
+	guilds := make([]shardGuild, 0, len(c.guilds))
+	for _, id := range sortedKeys(c.guilds) {
+		entry := c.guilds[id]
+		guilds = append(guilds, shardGuild{shard: entry.shard, guild: entry.snapshot()})
+	}
+	c.mu.RUnlock()
+
+	return scan(guilds, func(g shardGuild) bool {
+		return fn(g.shard, g.guild)
+	})
+}
+
+// deleteGuild must be called with the lock held.
+func (c *Cache) deleteGuild(guildID string) {
+	entry, ok := c.guilds[guildID]
+	if !ok {
+		return
+	}
+	for id := range entry.channels {
+		delete(c.channels, id)
+		delete(c.messages, id)
+	}
+	for id := range entry.threads {
+		delete(c.channels, id)
+		delete(c.messages, id)
+	}
+	delete(c.guilds, guildID)
+}
+
+// snapshot returns a copy of the guild with its roles and emojis.
+func (e *guildEntry) snapshot() structs.Guild {
+	guild := cloneGuild(e.guild)
+	guild.Roles = sortedValues(e.roles, cloneRole)
+	sort.SliceStable(guild.Roles, func(i, j int) bool {
+		return guild.Roles[i].Position < guild.Roles[j].Position
+	})
+	guild.Emojis = sortedValues(e.emojis, cloneEmoji)
+	return guild
+}
+
+func (e *guildEntry) setRoles(roles []structs.Role) {
+	e.roles = make(map[string]structs.Role, len(roles))
+	for _, role := range roles {
+		e.roles[role.ID.ToString()] = cloneRole(role)
+	}
+}
+
+func (e *guildEntry) setEmojis(emojis []structs.Emoji) {
+	e.emojis = make(map[string]structs.Emoji, len(emojis))
+	for _, emoji := range emojis {
+		if emoji.ID != nil {
+			e.emojis[emoji.ID.ToString()] = cloneEmoji(emoji)
+		}
+	}
+}
+
+// the channels of GUILD_CREATE don't carry the ID of their guild
+func withGuild(channel structs.Channel, guildID structs.Snowflake) structs.Channel {
+	if channel.GuildID == nil {
+		channel.GuildID = &guildID
+	}
+	return channel
+}
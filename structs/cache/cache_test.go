@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func testMember(userID uint64, roles ...uint64) structs.GuildMember {
+	member := structs.GuildMember{User: &structs.User{ID: *structs.NewSnowflake(userID)}}
+	for _, role := range roles {
+		member.Roles = append(member.Roles, *structs.NewSnowflake(role))
+	}
+	return member
+}
+
+func testMessage(channelID, messageID uint64) structs.Message {
+	return structs.Message{ID: *structs.NewSnowflake(messageID), ChannelID: *structs.NewSnowflake(channelID)}
+}
+
+// testServer returns a guild with the given ID and two text channels, IDs guildID+1 and guildID+2, and a thread, ID guildID+3.
+func testServer(guildID uint64, members ...structs.GuildMember) structs.Server {
+	server := structs.NewServer(&structs.Guild{
+		ID:    *structs.NewSnowflake(guildID),
+		Name:  "test",
+		Roles: []structs.Role{{ID: *structs.NewSnowflake(guildID), Name: "@everyone"}},
+	})
+	server.Channels = []structs.Channel{
+		{ID: *structs.NewSnowflake(guildID + 1), Type: structs.GuildTextChannel},
+		{ID: *structs.NewSnowflake(guildID + 2), Type: structs.GuildTextChannel},
+	}
+	server.Threads = []structs.Channel{{ID: *structs.NewSnowflake(guildID + 3), Type: structs.PublicThreadChannel}}
+	server.Members = members
+	return *server
+}
+
+func TestSetServerIndexesEntities(t *testing.T) {
+	c := New(Options{})
+	if err := c.SetServer(2, testServer(100, testMember(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	channel, _ := c.GetChannel(*structs.NewSnowflake(102))
+	if channel == nil || channel.GuildID == nil || channel.GuildID.ID != 100 {
+		t.Fatalf("channel = %+v, want channel 102 of guild 100", channel)
+	}
+	var channels []uint64
+	c.ScanChannels(*structs.NewSnowflake(100), func(channel structs.Channel) bool {
+		channels = append(channels, channel.ID.ID)
+		return true
+	})
+	if len(channels) != 3 {
+		t.Fatalf("guild channels = %v, want the 2 channels and the thread", channels)
+	}
+
+	if member, _ := c.GetMember(*structs.NewSnowflake(100), *structs.NewSnowflake(1)); member == nil {
+		t.Fatal("member 1 wasn't cached")
+	}
+	if role, _ := c.GetRole(*structs.NewSnowflake(100), *structs.NewSnowflake(100)); role == nil || role.Name != "@everyone" {
+		t.Fatalf("role = %+v, want @everyone", role)
+	}
+	if shard, ok, _ := c.GetGuildShard(*structs.NewSnowflake(100)); !ok || shard != 2 {
+		t.Fatalf("shard = %d, %v, want 2", shard, ok)
+	}
+}
+
+func TestDeleteGuildRemovesEntities(t *testing.T) {
+	c := New(Options{})
+	c.SetServer(0, testServer(100, testMember(1)))
+	c.SetMessage(testMessage(101, 1000))
+	c.DeleteGuild(*structs.NewSnowflake(100))
+
+	if channel, _ := c.GetChannel(*structs.NewSnowflake(101)); channel != nil {
+		t.Fatal("channel of the deleted guild is still cached")
+	}
+	if message, _ := c.GetMessage(*structs.NewSnowflake(101), *structs.NewSnowflake(1000)); message != nil {
+		t.Fatal("message of the deleted guild is still cached")
+	}
+	if stats := c.Stats(); stats.Guilds != 0 || stats.Channels != 0 || stats.Members != 0 || stats.Messages != 0 {
+		t.Fatalf("stats = %+v, want an empty cache", stats)
+	}
+}
+
+func TestAccessorsReturnCopies(t *testing.T) {
+	c := New(Options{})
+	c.SetServer(0, testServer(100, testMember(1, 7)))
+
+	member, _ := c.GetMember(*structs.NewSnowflake(100), *structs.NewSnowflake(1))
+	member.Roles[0] = *structs.NewSnowflake(8)
+	member.User.Username = "changed"
+
+	cached, _ := c.GetMember(*structs.NewSnowflake(100), *structs.NewSnowflake(1))
+	if cached.Roles[0].ID != 7 || cached.User.Username != "" {
+		t.Fatalf("cached member = %+v, want it unchanged", cached)
+	}
+}
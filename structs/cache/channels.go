@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// SetChannel caches a channel, or a thread when its type is one of the thread types.
+// Guild channels are indexed on their guild, channels without a guild ID (DMs) are only cached by ID.
+func (c *Cache) SetChannel(channel structs.Channel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setChannel(channel)
+	return nil
+}
+
+// setChannel must be called with the lock held.
+func (c *Cache) setChannel(channel structs.Channel) {
+	id := channel.ID.ToString()
+	if current, ok := c.channels[id]; ok && channel.Typing == nil {
+		// the typing tracker only lives in the cache
+		channel.Typing = current.Typing
+	}
+	if channel.Typing == nil {
+		channel.Typing = structs.NewTypingChannel()
+	}
+	channel.Messages = nil
+	c.channels[id] = cloneChannel(channel)
+
+	if channel.GuildID == nil {
+		return
+	}
+	entry, ok := c.guilds[channel.GuildID.ToString()]
+	if !ok {
+		return
+	}
+	if isThread(channel.Type) {
+		entry.threads[id] = struct{}{}
+	} else {
+		entry.channels[id] = struct{}{}
+	}
+}
+
+// GetChannel returns the channel or thread, nil if it isn't cached. The messages of the channel are not included, see `ScanMessages`.
+func (c *Cache) GetChannel(channelID structs.Snowflake) (*structs.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	channel, ok := c.channels[channelID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	channel = cloneChannel(channel)
+	return &channel, nil
+}
+
+// ScanChannels calls fn for every channel of the guild, then for every active thread, both sorted by ID.
+func (c *Cache) ScanChannels(guildID structs.Snowflake, fn func(channel structs.Channel) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	channels := append(c.channelList(entry.channels), c.channelList(entry.threads)...)
+	c.mu.RUnlock()
+
+	return scan(channels, fn)
+}
+
+// channelList must be called with the lock held.
+func (c *Cache) channelList(ids map[string]struct{}) []structs.Channel {
+	channels := make([]structs.Channel, 0, len(ids))
+	for _, id := range sortedKeys(ids) {
+		if channel, ok := c.channels[id]; ok {
+			channels = append(channels, cloneChannel(channel))
+		}
+	}
+	return channels
+}
+
+// DeleteChannel removes the channel or thread along with its messages.
+func (c *Cache) DeleteChannel(channelID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := channelID.ToString()
+	channel, ok := c.channels[id]
+	if ok && channel.GuildID != nil {
+		if entry, ok := c.guilds[channel.GuildID.ToString()]; ok {
+			delete(entry.channels, id)
+			delete(entry.threads, id)
+		}
+	}
+	delete(c.channels, id)
+	delete(c.messages, id)
+	return nil
+}
+
+// SetMessage caches the message in its channel, replacing the cached message with the same ID, unless the options leave it out.
+// When the channel holds more messages than `Options.MaxMessages`, the least recently used one is evicted.
+func (c *Cache) SetMessage(message structs.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	channelID := message.ChannelID.ToString()
+	if !c.allowMessage(message) {
+		if bucket, ok := c.messages[channelID]; ok {
+			bucket.delete(message.ID.ToString())
+		}
+		return nil
+	}
+
+	bucket, ok := c.messages[channelID]
+	if !ok {
+		bucket = newMessageBucket()
+		c.messages[channelID] = bucket
+	}
+	if evicted := bucket.set(cloneMessage(message), c.opts.MaxMessages); evicted > 0 {
+		c.stats.messageEvictions.Add(uint64(evicted))
+	}
+	return nil
+}
+
+// GetMessage returns the message, nil if it isn't cached. Getting a message counts as using it for the MaxMessages limit.
+func (c *Cache) GetMessage(channelID, messageID structs.Snowflake) (*structs.Message, error) {
+	// the lock is exclusive since the message moves to the front of its channel
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bucket, ok := c.messages[channelID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	message, ok := bucket.get(messageID.ToString())
+	if !ok {
+		return nil, nil
+	}
+	bucket.order.MoveToFront(bucket.elements[messageID.ToString()])
+	message = cloneMessage(message)
+	return &message, nil
+}
+
+// ScanMessages calls fn for every cached message of the channel, oldest first. Scanning doesn't count as using the messages.
+func (c *Cache) ScanMessages(channelID structs.Snowflake, fn func(message structs.Message) bool) error {
+	c.mu.RLock()
+	messages := c.channelMessages(channelID.ToString())
+	c.mu.RUnlock()
+
+	return scan(messages, fn)
+}
+
+// channelMessages must be called with the lock held.
+func (c *Cache) channelMessages(channelID string) []structs.Message {
+	bucket, ok := c.messages[channelID]
+	if !ok {
+		return nil
+	}
+	return sortedValues(bucket.values(), cloneMessage)
+}
+
+// DeleteMessage removes the message from the cache.
+func (c *Cache) DeleteMessage(channelID, messageID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := channelID.ToString()
+	bucket, ok := c.messages[id]
+	if !ok {
+		return nil
+	}
+	bucket.delete(messageID.ToString())
+	if len(bucket.elements) == 0 {
+		delete(c.messages, id)
+	}
+	return nil
+}
+
+func isThread(channelType structs.ChannelType) bool {
+	return channelType == structs.AnnouncementThreadChannel ||
+		channelType == structs.PublicThreadChannel ||
+		channelType == structs.PrivateThreadChannel
+}
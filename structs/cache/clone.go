@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"slices"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// the clone functions copy the entity along with the slices and pointers handlers usually edit,
+// so neither what is cached nor what is handed out can change the other
+
+func cloneGuild(guild structs.Guild) structs.Guild {
+	guild.Roles = slices.Clone(guild.Roles)
+	guild.Emojis = slices.Clone(guild.Emojis)
+	guild.Features = slices.Clone(guild.Features)
+	guild.Stickers = slices.Clone(guild.Stickers)
+	return guild
+}
+
+// cloneChannel keeps the typing tracker shared, it is live state rather than a value.
+func cloneChannel(channel structs.Channel) structs.Channel {
+	channel.PermissionOverwrites = slices.Clone(channel.PermissionOverwrites)
+	channel.Recipients = slices.Clone(channel.Recipients)
+	channel.AvailableTags = slices.Clone(channel.AvailableTags)
+	channel.AppliedTags = slices.Clone(channel.AppliedTags)
+	channel.Messages = slices.Clone(channel.Messages)
+	return channel
+}
+
+func cloneMember(member structs.GuildMember) structs.GuildMember {
+	if member.User != nil {
+		user := *member.User
+		member.User = &user
+	}
+	member.Roles = slices.Clone(member.Roles)
+	return member
+}
+
+func cloneRole(role structs.Role) structs.Role {
+	return role
+}
+
+func cloneEmoji(emoji structs.Emoji) structs.Emoji {
+	emoji.Roles = slices.Clone(emoji.Roles)
+	return emoji
+}
+
+func cloneVoiceState(voiceState structs.VoiceState) structs.VoiceState {
+	if voiceState.Member != nil {
+		member := cloneMember(*voiceState.Member)
+		voiceState.Member = &member
+	}
+	return voiceState
+}
+
+func clonePresence(presence structs.PresenceUpdate) structs.PresenceUpdate {
+	presence.Activities = slices.Clone(presence.Activities)
+	return presence
+}
+
+func cloneMessage(message structs.Message) structs.Message {
+	message.Mentions = slices.Clone(message.Mentions)
+	message.MentionRoles = slices.Clone(message.MentionRoles)
+	message.Attachments = slices.Clone(message.Attachments)
+	message.Embeds = slices.Clone(message.Embeds)
+	message.Reactions = slices.Clone(message.Reactions)
+	message.Components = slices.Clone(message.Components)
+	if message.Poll != nil {
+		poll := *message.Poll
+		poll.Answers = slices.Clone(poll.Answers)
+		message.Poll = &poll
+	}
+	return message
+}
+
+// sortedKeys returns the snowflake keys in ascending order, which is also the order the entities were created in.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareIDs)
+	return keys
+}
+
+// sortedValues returns clones of the values ordered by their snowflake keys.
+func sortedValues[V any](m map[string]V, clone func(V) V) []V {
+	values := make([]V, 0, len(m))
+	for _, key := range sortedKeys(m) {
+		values = append(values, clone(m[key]))
+	}
+	return values
+}
+
+// compareIDs orders snowflakes in their decimal form without parsing them, a shorter ID is a smaller one.
+func compareIDs(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// FileStore is a `Cache` persisted to a JSON snapshot file, so a restarted bot starts from the state it stopped with
+// instead of waiting for every `GUILD_CREATE`. Reads and writes go to memory, the snapshot is written every interval and on Close.
+type FileStore struct {
+	*Cache
+
+	path string
+	// serializes the writes of the snapshot file
+	saveMu *sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	once   *sync.Once
+}
+
+var _ CacheStore = (*FileStore)(nil)
+
+// snapshot is the content of the file of a FileStore.
+type snapshot struct {
+	Guilds []snapshotGuild `json:"guilds"`
+	// channels without a guild, i.e DMs
+	Channels []structs.Channel `json:"channels"`
+	Messages []structs.Message `json:"messages"`
+}
+
+type snapshotGuild struct {
+	Shard  int            `json:"shard"`
+	Server structs.Server `json:"server"`
+}
+
+// NewFileStore creates a store persisted to the file at path, loading the snapshot the file holds when it exists.
+//
+// Parameters:
+//   - path: The file of the snapshot, written atomically through a temporary file in the same directory.
+//   - opts: The options of the in-memory cache, see `Options`.
+//   - interval: How often the snapshot is written, 0 only writes it on Save and Close.
+//
+// Returns:
+//   - *FileStore: The store, call Close to stop it and write the last snapshot.
+//   - error: An error if the snapshot can't be read.
+//
+// Example usage:
+//
+//	store, err := cache.NewFileStore("state.json", cache.Options{MaxMessages: 100}, time.Minute)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer store.Close()
+//	bot, stopChan, err := bot.NewBot("", token, intents, bot.WithCacheStore(store))
+func NewFileStore(path string, opts Options, interval time.Duration) (*FileStore, error) {
+	f := &FileStore{
+		Cache:  New(opts),
+		path:   path,
+		saveMu: &sync.Mutex{},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		once:   &sync.Once{},
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		close(f.done)
+		return f, nil
+	}
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.Save()
+			case <-f.stop:
+				return
+			}
+		}
+	}()
+	return f, nil
+}
+
+// Save writes the snapshot of the store to its file.
+func (f *FileStore) Save() error {
+	data, err := json.Marshal(f.snapshot())
+	if err != nil {
+		return err
+	}
+
+	f.saveMu.Lock()
+	defer f.saveMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Close stops the periodic snapshots and writes the last one. The store stays usable in memory.
+func (f *FileStore) Close() error {
+	f.once.Do(func() {
+		close(f.stop)
+	})
+	<-f.done
+	return f.Save()
+}
+
+func (f *FileStore) load() error {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	for _, guild := range snap.Guilds {
+		f.SetServer(guild.Shard, guild.Server)
+	}
+	for _, channel := range snap.Channels {
+		f.SetChannel(channel)
+	}
+	// least recently used first, so the order of the messages survives
+	for _, message := range snap.Messages {
+		f.SetMessage(message)
+	}
+	return nil
+}
+
+// snapshot copies the content of the cache.
+func (c *Cache) snapshot() snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var snap snapshot
+	for _, id := range sortedKeys(c.guilds) {
+		entry := c.guilds[id]
+		server := c.server(entry)
+		snap.Guilds = append(snap.Guilds, snapshotGuild{Shard: entry.shard, Server: *server})
+	}
+	for _, id := range sortedKeys(c.channels) {
+		if channel := c.channels[id]; channel.GuildID == nil {
+			snap.Channels = append(snap.Channels, cloneChannel(channel))
+		}
+	}
+	for _, id := range sortedKeys(c.messages) {
+		bucket := c.messages[id]
+		for element := bucket.order.Back(); element != nil; element = element.Prev() {
+			snap.Messages = append(snap.Messages, cloneMessage(element.Value.(structs.Message)))
+		}
+	}
+	return snap
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	guildID := *structs.NewSnowflake(100)
+
+	store, err := NewFileStore(path, Options{MaxMessages: 3}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetServer(4, testServer(100, testMember(1, 100)))
+	store.SetChannel(structs.Channel{ID: *structs.NewSnowflake(500), Type: structs.DMChannel})
+	for id := uint64(1); id <= 3; id++ {
+		store.SetMessage(testMessage(101, id))
+	}
+	// message 2 becomes the least recently used
+	store.GetMessage(*structs.NewSnowflake(101), *structs.NewSnowflake(1))
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewFileStore(path, Options{MaxMessages: 3}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shard, ok, _ := loaded.GetGuildShard(guildID); !ok || shard != 4 {
+		t.Fatalf("shard = %d, %v, want 4", shard, ok)
+	}
+	if member, _ := loaded.GetMember(guildID, *structs.NewSnowflake(1)); member == nil || len(member.Roles) != 1 {
+		t.Fatalf("member = %+v, want member 1 with its role", member)
+	}
+	if role, _ := loaded.GetRole(guildID, guildID); role == nil || role.Name != "@everyone" {
+		t.Fatalf("role = %+v, want @everyone", role)
+	}
+	if channel, _ := loaded.GetChannel(*structs.NewSnowflake(500)); channel == nil || channel.Type != structs.DMChannel {
+		t.Fatalf("channel = %+v, want the DM channel", channel)
+	}
+	if thread, _ := loaded.GetChannel(*structs.NewSnowflake(103)); thread == nil || thread.GuildID == nil || !thread.GuildID.Equals(guildID) {
+		t.Fatalf("thread = %+v, want the thread of guild 100", thread)
+	}
+
+	// the order the messages were used in survives the restart
+	loaded.SetMessage(testMessage(101, 4))
+	if got, want := cachedMessageIDs(loaded.Cache, 101), []uint64{1, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages = %v, want %v", got, want)
+	}
+}
+
+func TestFileStoreWithoutFile(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "missing.json"), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := store.Stats(); stats.Guilds != 0 || stats.Channels != 0 {
+		t.Fatalf("stats = %+v, want an empty store", stats)
+	}
+}
+
+func TestFileStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path, Options{}, 0); err == nil {
+		t.Fatal("NewFileStore() loaded a corrupt snapshot")
+	}
+}
+
+func TestFileStoreSavesPeriodically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := NewFileStore(path, Options{}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetServer(0, testServer(100))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot wasn't written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// once closed, only the snapshot is left behind, not the temporary files it was written through
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("directory has %d files, want only the snapshot", len(entries))
+	}
+}
@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// SetMember caches the member of the guild, members without a user are ignored. Does nothing when the guild isn't cached,
+// or when the options leave the member out.
+func (c *Cache) SetMember(guildID structs.Snowflake, member structs.GuildMember) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweepMembers(time.Now())
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil
+	}
+	if !c.allowMember(guildID, member) {
+		// a member that no longer passes the filter must not linger with stale data
+		if member.User != nil {
+			delete(entry.members, member.User.ID.ToString())
+			delete(entry.memberSeen, member.User.ID.ToString())
+		}
+		return nil
+	}
+	c.setMember(entry, member)
+	return nil
+}
+
+// GetMember returns the member of the guild, nil if it isn't cached.
+func (c *Cache) GetMember(guildID, userID structs.Snowflake) (*structs.GuildMember, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	member, ok := entry.members[userID.ToString()]
+	if !ok || c.memberExpired(entry, userID.ToString(), time.Now()) {
+		return nil, nil
+	}
+	member = cloneMember(member)
+	return &member, nil
+}
+
+// ScanMembers calls fn for every cached member of the guild, sorted by user ID.
+func (c *Cache) ScanMembers(guildID structs.Snowflake, fn func(member structs.GuildMember) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	members := c.memberList(entry)
+	c.mu.RUnlock()
+
+	return scan(members, fn)
+}
+
+// memberList returns the members of the guild that haven't expired. Must be called with the lock held.
+func (c *Cache) memberList(entry *guildEntry) []structs.GuildMember {
+	now := time.Now()
+	members := make([]structs.GuildMember, 0, len(entry.members))
+	for _, id := range sortedKeys(entry.members) {
+		if !c.memberExpired(entry, id, now) {
+			members = append(members, cloneMember(entry.members[id]))
+		}
+	}
+	return members
+}
+
+// DeleteMember removes the member from the guild.
+func (c *Cache) DeleteMember(guildID, userID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		delete(entry.members, userID.ToString())
+		delete(entry.memberSeen, userID.ToString())
+	}
+	return nil
+}
+
+// SetRole caches the role of the guild, replacing the cached role with the same ID.
+func (c *Cache) SetRole(guildID structs.Snowflake, role structs.Role) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		entry.roles[role.ID.ToString()] = cloneRole(role)
+	}
+	return nil
+}
+
+// GetRole returns the role of the guild, nil if it isn't cached.
+func (c *Cache) GetRole(guildID, roleID structs.Snowflake) (*structs.Role, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	role, ok := entry.roles[roleID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	role = cloneRole(role)
+	return &role, nil
+}
+
+// ScanRoles calls fn for every role of the guild, sorted by position.
+func (c *Cache) ScanRoles(guildID structs.Snowflake, fn func(role structs.Role) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	roles := sortedValues(entry.roles, cloneRole)
+	c.mu.RUnlock()
+
+	sort.SliceStable(roles, func(i, j int) bool {
+		return roles[i].Position < roles[j].Position
+	})
+	return scan(roles, fn)
+}
+
+// DeleteRole removes the role from the guild.
+func (c *Cache) DeleteRole(guildID, roleID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		delete(entry.roles, roleID.ToString())
+	}
+	return nil
+}
+
+// SetEmoji caches the custom emoji of the guild, emojis without an ID are ignored.
+func (c *Cache) SetEmoji(guildID structs.Snowflake, emoji structs.Emoji) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok && emoji.ID != nil {
+		entry.emojis[emoji.ID.ToString()] = cloneEmoji(emoji)
+	}
+	return nil
+}
+
+// GetEmoji returns the custom emoji of the guild, nil if it isn't cached.
+func (c *Cache) GetEmoji(guildID, emojiID structs.Snowflake) (*structs.Emoji, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	emoji, ok := entry.emojis[emojiID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	emoji = cloneEmoji(emoji)
+	return &emoji, nil
+}
+
+// ScanEmojis calls fn for every custom emoji of the guild, sorted by ID.
+func (c *Cache) ScanEmojis(guildID structs.Snowflake, fn func(emoji structs.Emoji) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	emojis := sortedValues(entry.emojis, cloneEmoji)
+	c.mu.RUnlock()
+
+	return scan(emojis, fn)
+}
+
+// DeleteEmoji removes the custom emoji from the guild.
+func (c *Cache) DeleteEmoji(guildID, emojiID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		delete(entry.emojis, emojiID.ToString())
+	}
+	return nil
+}
+
+// SetVoiceState caches the voice state of the user, a voice state without a channel means the user left and removes it.
+func (c *Cache) SetVoiceState(guildID structs.Snowflake, voiceState structs.VoiceState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok || c.opts.DisableVoiceStates {
+		return nil
+	}
+	if voiceState.ChannelID == nil {
+		delete(entry.voiceStates, voiceState.UserID.ToString())
+		return nil
+	}
+	entry.voiceStates[voiceState.UserID.ToString()] = cloneVoiceState(voiceState)
+	return nil
+}
+
+// GetVoiceState returns the voice state of the user in the guild, nil if the user isn't in a voice channel.
+func (c *Cache) GetVoiceState(guildID, userID structs.Snowflake) (*structs.VoiceState, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	voiceState, ok := entry.voiceStates[userID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	voiceState = cloneVoiceState(voiceState)
+	return &voiceState, nil
+}
+
+// ScanVoiceStates calls fn for every voice state of the guild, sorted by user ID.
+func (c *Cache) ScanVoiceStates(guildID structs.Snowflake, fn func(voiceState structs.VoiceState) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	voiceStates := sortedValues(entry.voiceStates, cloneVoiceState)
+	c.mu.RUnlock()
+
+	return scan(voiceStates, fn)
+}
+
+// DeleteVoiceState removes the voice state of the user from the guild.
+func (c *Cache) DeleteVoiceState(guildID, userID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		delete(entry.voiceStates, userID.ToString())
+	}
+	return nil
+}
+
+// SetPresence caches the presence of the user in the guild, unless the options leave it out.
+func (c *Cache) SetPresence(guildID structs.Snowflake, presence structs.PresenceUpdate) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil
+	}
+	if !c.allowPresence(guildID, presence) {
+		delete(entry.presences, presence.User.ID.ToString())
+		return nil
+	}
+	entry.presences[presence.User.ID.ToString()] = clonePresence(presence)
+	return nil
+}
+
+// GetPresence returns the presence of the user in the guild, nil if it isn't cached.
+func (c *Cache) GetPresence(guildID, userID structs.Snowflake) (*structs.PresenceUpdate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	presence, ok := entry.presences[userID.ToString()]
+	if !ok {
+		return nil, nil
+	}
+	presence = clonePresence(presence)
+	return &presence, nil
+}
+
+// ScanPresences calls fn for every cached presence of the guild, sorted by user ID.
+func (c *Cache) ScanPresences(guildID structs.Snowflake, fn func(presence structs.PresenceUpdate) bool) error {
+	c.mu.RLock()
+	entry, ok := c.guilds[guildID.ToString()]
+	if !ok {
+		c.mu.RUnlock()
+		return nil
+	}
+	presences := sortedValues(entry.presences, clonePresence)
+	c.mu.RUnlock()
+
+	return scan(presences, fn)
+}
+
+// DeletePresence removes the presence of the user from the guild.
+func (c *Cache) DeletePresence(guildID, userID structs.Snowflake) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.guilds[guildID.ToString()]; ok {
+		delete(entry.presences, userID.ToString())
+	}
+	return nil
+}
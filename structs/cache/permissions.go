@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// ErrNotCached is returned when the permissions can't be computed because the guild, its roles, the member or the channel isn't stored.
+var ErrNotCached = errors.New("not cached")
+
+// Permissions computes the permissions of the user in the channel from the store, see `structs.ComputePermissions`.
+// Threads take the overwrites of their parent channel.
+//
+// Parameters:
+//   - store: The store holding the guild, the member and the channel.
+//   - guildID: The ID of the guild.
+//   - userID: The ID of the member.
+//   - channelID: The ID of the channel or thread, nil for the permissions of the member in the guild.
+//
+// Returns:
+//   - structs.Permission: The permissions of the member.
+//   - error: ErrNotCached when an entity needed isn't stored, or the error of the store.
+func Permissions(store CacheStore, guildID, userID structs.Snowflake, channelID *structs.Snowflake) (structs.Permission, error) {
+	member, err := store.GetMember(guildID, userID)
+	if err != nil {
+		return 0, err
+	} else if member == nil {
+		return 0, ErrNotCached
+	}
+	return MemberPermissions(store, guildID, *member, channelID)
+}
+
+// MemberPermissions is `Permissions` for a member that isn't read from the store, i.e the member of an interaction.
+func MemberPermissions(store CacheStore, guildID structs.Snowflake, member structs.GuildMember, channelID *structs.Snowflake) (structs.Permission, error) {
+	guild, err := store.GetGuild(guildID)
+	if err != nil {
+		return 0, err
+	} else if guild == nil || len(guild.Roles) == 0 {
+		return 0, ErrNotCached
+	}
+	if channelID == nil {
+		return structs.ComputePermissions(*guild, member, nil), nil
+	}
+
+	channel, err := overwriteChannel(store, *channelID)
+	if err != nil {
+		return 0, err
+	} else if channel.GuildID == nil || !channel.GuildID.Equals(guildID) {
+		return 0, errors.New("channel is not in the guild")
+	}
+	return structs.ComputePermissions(*guild, member, channel), nil
+}
+
+// overwriteChannel returns the channel whose overwrites apply in the channel, the parent channel of a thread.
+func overwriteChannel(store CacheStore, channelID structs.Snowflake) (*structs.Channel, error) {
+	channel, err := store.GetChannel(channelID)
+	if err != nil {
+		return nil, err
+	} else if channel == nil {
+		return nil, ErrNotCached
+	}
+	if !isThread(channel.Type) || channel.ParentID == nil {
+		return channel, nil
+	}
+
+	parent, err := store.GetChannel(*channel.ParentID)
+	if err != nil {
+		return nil, err
+	} else if parent == nil {
+		return nil, ErrNotCached
+	}
+	return parent, nil
+}
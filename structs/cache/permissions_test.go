@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func TestPermissions(t *testing.T) {
+	guildID := *structs.NewSnowflake(100)
+	server := testServer(100, testMember(1))
+	server.Roles[0].Permissions = structs.NewBitfield(structs.ViewChannel, structs.SendMessages)
+	// channel 101 hides itself from @everyone, and thread 103 lives in it
+	server.Channels[0].PermissionOverwrites = []structs.Overwrite{{ID: guildID, Deny: structs.NewBitfield(structs.ViewChannel)}}
+	server.Threads[0].ParentID = structs.NewSnowflake(101)
+
+	c := New(Options{})
+	c.SetServer(0, server)
+
+	tests := []struct {
+		name      string
+		userID    uint64
+		channelID *structs.Snowflake
+		want      structs.Permission
+		wantErr   error
+	}{
+		{name: "guild", userID: 1, want: structs.ViewChannel | structs.SendMessages},
+		{name: "channel", userID: 1, channelID: structs.NewSnowflake(102), want: structs.ViewChannel | structs.SendMessages},
+		{name: "channel overwrites", userID: 1, channelID: structs.NewSnowflake(101), want: 0},
+		{name: "thread takes the overwrites of its parent", userID: 1, channelID: structs.NewSnowflake(103), want: 0},
+		{name: "member not cached", userID: 2, wantErr: ErrNotCached},
+		{name: "channel not cached", userID: 1, channelID: structs.NewSnowflake(999), wantErr: ErrNotCached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Permissions(c, guildID, *structs.NewSnowflake(tt.userID), tt.channelID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Permissions() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Permissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsChannelOfAnotherGuild(t *testing.T) {
+	c := New(Options{})
+	c.SetServer(0, testServer(100, testMember(1)))
+	c.SetServer(0, testServer(200))
+
+	if _, err := Permissions(c, *structs.NewSnowflake(100), *structs.NewSnowflake(1), structs.NewSnowflake(201)); err == nil {
+		t.Fatal("Permissions() computed the permissions in a channel of another guild")
+	}
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"container/list"
+	"sync/atomic"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// Options configures what the cache keeps. The zero value caches everything, without limits.
+// The member of the bot itself is always cached, whatever the options, since permissions are computed from it.
+type Options struct {
+	// DisableMembers stops caching guild members.
+	DisableMembers bool
+	// DisablePresences stops caching presences.
+	DisablePresences bool
+	// DisableMessages stops caching messages.
+	DisableMessages bool
+	// DisableVoiceStates stops caching voice states.
+	DisableVoiceStates bool
+
+	// MaxMessages caps the messages cached per channel, the least recently used message is evicted to make room. 0 means no limit.
+	MaxMessages int
+	// MemberTTL drops members that haven't been updated for the duration. 0 keeps members until they leave.
+	MemberTTL time.Duration
+
+	// MemberFilter decides which members are cached, i.e only members with roles.
+	MemberFilter func(guildID structs.Snowflake, member structs.GuildMember) bool
+	// PresenceFilter decides which presences are cached, i.e only online users.
+	PresenceFilter func(guildID structs.Snowflake, presence structs.PresenceUpdate) bool
+	// MessageFilter decides which messages are cached, i.e only messages from guilds.
+	MessageFilter func(message structs.Message) bool
+}
+
+// Stats is a snapshot of what the cache holds, and of what it let go of.
+type Stats struct {
+	Guilds      int
+	Channels    int
+	Members     int
+	Roles       int
+	Emojis      int
+	VoiceStates int
+	Presences   int
+	Messages    int
+
+	// MessageEvictions is the total amount of messages evicted by the MaxMessages limit.
+	MessageEvictions uint64
+	// MemberExpirations is the total amount of members dropped by the MemberTTL.
+	MemberExpirations uint64
+	// Filtered is the total amount of members, presences and messages rejected by the filters.
+	Filtered uint64
+}
+
+type cacheStats struct {
+	messageEvictions  atomic.Uint64
+	memberExpirations atomic.Uint64
+	filtered          atomic.Uint64
+}
+
+// Stats returns the amount of cached entities, and the eviction counters.
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := Stats{
+		Guilds:            len(c.guilds),
+		Channels:          len(c.channels),
+		MessageEvictions:  c.stats.messageEvictions.Load(),
+		MemberExpirations: c.stats.memberExpirations.Load(),
+		Filtered:          c.stats.filtered.Load(),
+	}
+	now := time.Now()
+	for _, entry := range c.guilds {
+		for id := range entry.members {
+			if !c.memberExpired(entry, id, now) {
+				stats.Members++
+			}
+		}
+		stats.Roles += len(entry.roles)
+		stats.Emojis += len(entry.emojis)
+		stats.VoiceStates += len(entry.voiceStates)
+		stats.Presences += len(entry.presences)
+	}
+	for _, bucket := range c.messages {
+		stats.Messages += len(bucket.elements)
+	}
+	return stats
+}
+
+// Options returns the options the cache was created with.
+func (c *Cache) Options() Options {
+	return c.opts
+}
+
+// SetSelfID tells the cache which user the bot is, so its member is kept whatever the options.
+func (c *Cache) SetSelfID(userID structs.Snowflake) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.selfID = userID.ToString()
+}
+
+// allowMember must be called with the lock held.
+func (c *Cache) allowMember(guildID structs.Snowflake, member structs.GuildMember) bool {
+	if member.User == nil {
+		return false
+	}
+	if member.User.ID.ToString() == c.selfID {
+		return true
+	}
+	if c.opts.DisableMembers {
+		return false
+	}
+	if c.opts.MemberFilter != nil && !c.opts.MemberFilter(guildID, member) {
+		c.stats.filtered.Add(1)
+		return false
+	}
+	return true
+}
+
+func (c *Cache) allowPresence(guildID structs.Snowflake, presence structs.PresenceUpdate) bool {
+	if c.opts.DisablePresences {
+		return false
+	}
+	if c.opts.PresenceFilter != nil && !c.opts.PresenceFilter(guildID, presence) {
+		c.stats.filtered.Add(1)
+		return false
+	}
+	return true
+}
+
+func (c *Cache) allowMessage(message structs.Message) bool {
+	if c.opts.DisableMessages {
+		return false
+	}
+	if c.opts.MessageFilter != nil && !c.opts.MessageFilter(message) {
+		c.stats.filtered.Add(1)
+		return false
+	}
+	return true
+}
+
+// setMember must be called with the lock held, the member is expected to be allowed.
+func (c *Cache) setMember(entry *guildEntry, member structs.GuildMember) {
+	id := member.User.ID.ToString()
+	entry.members[id] = cloneMember(member)
+	if c.opts.MemberTTL > 0 && id != c.selfID {
+		entry.memberSeen[id] = time.Now()
+	}
+}
+
+// memberExpired must be called with the lock held.
+func (c *Cache) memberExpired(entry *guildEntry, userID string, now time.Time) bool {
+	if c.opts.MemberTTL <= 0 {
+		return false
+	}
+	seen, ok := entry.memberSeen[userID]
+	return ok && now.Sub(seen) >= c.opts.MemberTTL
+}
+
+// sweepMembers drops the expired members of every guild, at most once per TTL or minute. Must be called with the lock held.
+func (c *Cache) sweepMembers(now time.Time) {
+	interval := min(c.opts.MemberTTL, time.Minute)
+	if c.opts.MemberTTL <= 0 || now.Sub(c.lastSweep) < interval {
+		return
+	}
+	c.lastSweep = now
+
+	for _, entry := range c.guilds {
+		for id := range entry.memberSeen {
+			if c.memberExpired(entry, id, now) {
+				delete(entry.members, id)
+				delete(entry.memberSeen, id)
+				c.stats.memberExpirations.Add(1)
+			}
+		}
+	}
+}
+
+// messageBucket holds the messages of a channel, most recently used first.
+type messageBucket struct {
+	elements map[string]*list.Element
+	order    *list.List
+}
+
+func newMessageBucket() *messageBucket {
+	return &messageBucket{
+		elements: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// set adds or replaces the message as the most recently used, evicting the least recently used messages over the limit.
+func (b *messageBucket) set(message structs.Message, limit int) (evicted int) {
+	id := message.ID.ToString()
+	if element, ok := b.elements[id]; ok {
+		element.Value = message
+		b.order.MoveToFront(element)
+	} else {
+		b.elements[id] = b.order.PushFront(message)
+	}
+
+	for limit > 0 && b.order.Len() > limit {
+		oldest := b.order.Remove(b.order.Back()).(structs.Message)
+		delete(b.elements, oldest.ID.ToString())
+		evicted++
+	}
+	return evicted
+}
+
+func (b *messageBucket) get(messageID string) (structs.Message, bool) {
+	element, ok := b.elements[messageID]
+	if !ok {
+		return structs.Message{}, false
+	}
+	return element.Value.(structs.Message), true
+}
+
+func (b *messageBucket) delete(messageID string) {
+	if element, ok := b.elements[messageID]; ok {
+		b.order.Remove(element)
+		delete(b.elements, messageID)
+	}
+}
+
+// values returns the messages keyed by ID.
+func (b *messageBucket) values() map[string]structs.Message {
+	messages := make(map[string]structs.Message, len(b.elements))
+	for id, element := range b.elements {
+		messages[id] = element.Value.(structs.Message)
+	}
+	return messages
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func cachedMessageIDs(c *Cache, channelID uint64) []uint64 {
+	var ids []uint64
+	c.ScanMessages(*structs.NewSnowflake(channelID), func(message structs.Message) bool {
+		ids = append(ids, message.ID.ID)
+		return true
+	})
+	return ids
+}
+
+func TestMaxMessagesEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(Options{MaxMessages: 3})
+	for id := uint64(1); id <= 3; id++ {
+		c.SetMessage(testMessage(10, id))
+	}
+
+	// reading message 1 makes message 2 the least recently used
+	if message, _ := c.GetMessage(*structs.NewSnowflake(10), *structs.NewSnowflake(1)); message == nil {
+		t.Fatal("message 1 isn't cached")
+	}
+	c.SetMessage(testMessage(10, 4))
+	if got, want := cachedMessageIDs(c, 10), []uint64{1, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages = %v, want %v", got, want)
+	}
+
+	// updating a message uses it too
+	c.SetMessage(testMessage(10, 3))
+	c.SetMessage(testMessage(10, 5))
+	if got, want := cachedMessageIDs(c, 10), []uint64{3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages = %v, want %v", got, want)
+	}
+
+	// the limit is per channel
+	c.SetMessage(testMessage(20, 6))
+	if stats := c.Stats(); stats.Messages != 4 || stats.MessageEvictions != 2 {
+		t.Fatalf("stats = %+v, want 4 messages and 2 evictions", stats)
+	}
+}
+
+func TestMemberTTL(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	c := New(Options{MemberTTL: ttl})
+	c.SetSelfID(*structs.NewSnowflake(1))
+	c.SetServer(0, testServer(100, testMember(1), testMember(2), testMember(3)))
+
+	guildID := *structs.NewSnowflake(100)
+	time.Sleep(ttl / 2)
+	// updating a member restarts its TTL
+	c.SetMember(guildID, testMember(3))
+	time.Sleep(ttl/2 + 10*time.Millisecond)
+
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(2)); member != nil {
+		t.Fatal("member 2 outlived its TTL")
+	}
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(3)); member == nil {
+		t.Fatal("member 3 expired although it was updated")
+	}
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(1)); member == nil {
+		t.Fatal("the member of the bot expired")
+	}
+	if stats := c.Stats(); stats.Members != 2 {
+		t.Fatalf("stats = %+v, want 2 members", stats)
+	}
+
+	// writes sweep the expired members out of the cache
+	c.SetMember(guildID, testMember(4))
+	if stats := c.Stats(); stats.MemberExpirations != 1 {
+		t.Fatalf("stats = %+v, want 1 expiration", stats)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	guildID := *structs.NewSnowflake(100)
+	c := New(Options{
+		MemberFilter: func(_ structs.Snowflake, member structs.GuildMember) bool {
+			return len(member.Roles) > 0
+		},
+		PresenceFilter: func(_ structs.Snowflake, presence structs.PresenceUpdate) bool {
+			return presence.Status != structs.UserOffline
+		},
+		MessageFilter: func(message structs.Message) bool {
+			return message.Author.IsBot == nil || !*message.Author.IsBot
+		},
+	})
+	c.SetSelfID(*structs.NewSnowflake(1))
+	c.SetServer(0, testServer(100, testMember(1), testMember(2), testMember(3, 7)))
+
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(1)); member == nil {
+		t.Fatal("the member of the bot was filtered out")
+	}
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(2)); member != nil {
+		t.Fatal("member 2 without roles was cached")
+	}
+
+	// a member that stops passing the filter is removed
+	c.SetMember(guildID, testMember(3))
+	if member, _ := c.GetMember(guildID, *structs.NewSnowflake(3)); member != nil {
+		t.Fatal("member 3 is still cached after losing its roles")
+	}
+
+	c.SetPresence(guildID, structs.PresenceUpdate{User: structs.User{ID: *structs.NewSnowflake(3)}, Status: structs.UserOnline})
+	c.SetPresence(guildID, structs.PresenceUpdate{User: structs.User{ID: *structs.NewSnowflake(4)}, Status: structs.UserOffline})
+	if presence, _ := c.GetPresence(guildID, *structs.NewSnowflake(4)); presence != nil {
+		t.Fatal("offline presence was cached")
+	}
+
+	bot := testMessage(101, 1000)
+	isBot := true
+	bot.Author.IsBot = &isBot
+	c.SetMessage(bot)
+	c.SetMessage(testMessage(101, 1001))
+	if got, want := cachedMessageIDs(c, 101), []uint64{1001}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages = %v, want %v", got, want)
+	}
+
+	if stats := c.Stats(); stats.Members != 1 || stats.Presences != 1 || stats.Filtered != 4 {
+		t.Fatalf("stats = %+v, want 1 member, 1 presence and 4 filtered", stats)
+	}
+}
+
+func TestDisabledEntities(t *testing.T) {
+	guildID := *structs.NewSnowflake(100)
+	c := New(Options{DisableMembers: true, DisableMessages: true, DisablePresences: true, DisableVoiceStates: true})
+	c.SetSelfID(*structs.NewSnowflake(1))
+	c.SetServer(0, testServer(100, testMember(1), testMember(2)))
+	c.SetMessage(testMessage(101, 1000))
+	c.SetPresence(guildID, structs.PresenceUpdate{User: structs.User{ID: *structs.NewSnowflake(2)}, Status: structs.UserOnline})
+	c.SetVoiceState(guildID, structs.VoiceState{UserID: *structs.NewSnowflake(2), ChannelID: structs.NewSnowflake(101)})
+
+	// the member of the bot is kept since permissions are computed from it
+	if stats := c.Stats(); stats.Members != 1 || stats.Messages != 0 || stats.Presences != 0 || stats.VoiceStates != 0 {
+		t.Fatalf("stats = %+v, want only the member of the bot", stats)
+	}
+}
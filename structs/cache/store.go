@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// CacheStore is the storage behind the state of a bot, the handlers of the sessions write every gateway update through it.
+// `Cache` keeps the state in memory and `FileStore` persists it to a JSON snapshot, implement the interface to share the
+// state between processes (Redis, a database, ...) without touching the handlers.
+//
+// Getters return nil, and no error, when the entity isn't stored. Scans call fn for every stored entity until it returns false,
+// fn is free to call back into the store. Implementations must be safe for concurrent use, and must return copies
+// so changing what is returned never changes what is stored.
+type CacheStore interface {
+	GuildStore
+	ChannelStore
+	MemberStore
+	RoleStore
+	EmojiStore
+	VoiceStateStore
+	PresenceStore
+	MessageStore
+}
+
+// GuildStore stores guilds, along with the shard handling each of them.
+type GuildStore interface {
+	// SetServer stores a guild and everything `GUILD_CREATE` carries with it, replacing what was stored for the guild.
+	SetServer(shard int, server structs.Server) error
+	// GetServer returns the guild with every entity stored for it.
+	GetServer(guildID structs.Snowflake) (*structs.Server, error)
+	// SetGuild updates the guild, keeping the entities stored with it. The roles and emojis of the guild replace the stored ones when set.
+	SetGuild(shard int, guild structs.Guild) error
+	// GetGuild returns the guild with its roles and emojis.
+	GetGuild(guildID structs.Snowflake) (*structs.Guild, error)
+	// GetGuildShard returns the shard handling the guild, ok is false when the guild isn't stored.
+	GetGuildShard(guildID structs.Snowflake) (shard int, ok bool, err error)
+	// DeleteGuild removes the guild and every entity stored with it.
+	DeleteGuild(guildID structs.Snowflake) error
+	// ScanGuilds calls fn for every guild, with the shard handling it.
+	ScanGuilds(fn func(shard int, guild structs.Guild) bool) error
+}
+
+// ChannelStore stores channels and threads.
+type ChannelStore interface {
+	SetChannel(channel structs.Channel) error
+	GetChannel(channelID structs.Snowflake) (*structs.Channel, error)
+	// DeleteChannel removes the channel along with its messages.
+	DeleteChannel(channelID structs.Snowflake) error
+	// ScanChannels calls fn for every channel and thread of the guild.
+	ScanChannels(guildID structs.Snowflake, fn func(channel structs.Channel) bool) error
+}
+
+// MemberStore stores the members of guilds.
+type MemberStore interface {
+	SetMember(guildID structs.Snowflake, member structs.GuildMember) error
+	GetMember(guildID, userID structs.Snowflake) (*structs.GuildMember, error)
+	DeleteMember(guildID, userID structs.Snowflake) error
+	ScanMembers(guildID structs.Snowflake, fn func(member structs.GuildMember) bool) error
+}
+
+// RoleStore stores the roles of guilds.
+type RoleStore interface {
+	SetRole(guildID structs.Snowflake, role structs.Role) error
+	GetRole(guildID, roleID structs.Snowflake) (*structs.Role, error)
+	DeleteRole(guildID, roleID structs.Snowflake) error
+	ScanRoles(guildID structs.Snowflake, fn func(role structs.Role) bool) error
+}
+
+// EmojiStore stores the custom emojis of guilds.
+type EmojiStore interface {
+	SetEmoji(guildID structs.Snowflake, emoji structs.Emoji) error
+	GetEmoji(guildID, emojiID structs.Snowflake) (*structs.Emoji, error)
+	DeleteEmoji(guildID, emojiID structs.Snowflake) error
+	ScanEmojis(guildID structs.Snowflake, fn func(emoji structs.Emoji) bool) error
+}
+
+// VoiceStateStore stores the voice states of the users in a voice channel.
+type VoiceStateStore interface {
+	SetVoiceState(guildID structs.Snowflake, voiceState structs.VoiceState) error
+	GetVoiceState(guildID, userID structs.Snowflake) (*structs.VoiceState, error)
+	DeleteVoiceState(guildID, userID structs.Snowflake) error
+	ScanVoiceStates(guildID structs.Snowflake, fn func(voiceState structs.VoiceState) bool) error
+}
+
+// PresenceStore stores the presences of the users of guilds.
+type PresenceStore interface {
+	SetPresence(guildID structs.Snowflake, presence structs.PresenceUpdate) error
+	GetPresence(guildID, userID structs.Snowflake) (*structs.PresenceUpdate, error)
+	DeletePresence(guildID, userID structs.Snowflake) error
+	ScanPresences(guildID structs.Snowflake, fn func(presence structs.PresenceUpdate) bool) error
+}
+
+// MessageStore stores the messages of channels.
+type MessageStore interface {
+	SetMessage(message structs.Message) error
+	GetMessage(channelID, messageID structs.Snowflake) (*structs.Message, error)
+	DeleteMessage(channelID, messageID structs.Snowflake) error
+	// ScanMessages calls fn for every message of the channel, oldest first.
+	ScanMessages(channelID structs.Snowflake, fn func(message structs.Message) bool) error
+}
+
+// Servers returns the servers of the shard from the store, keyed by guild ID. A negative shard returns the guilds of every shard.
+func Servers(store CacheStore, shard int) (map[string]*structs.Server, error) {
+	var guildIDs []structs.Snowflake
+	err := store.ScanGuilds(func(guildShard int, guild structs.Guild) bool {
+		if shard < 0 || guildShard == shard {
+			guildIDs = append(guildIDs, guild.ID)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]*structs.Server, len(guildIDs))
+	for _, id := range guildIDs {
+		server, err := store.GetServer(id)
+		if err != nil {
+			return nil, err
+		}
+		if server != nil {
+			servers[id.ToString()] = server
+		}
+	}
+	return servers, nil
+}
+
+// scan calls fn for each value until it returns false, the values are copied beforehand so fn runs without the lock.
+func scan[T any](values []T, fn func(T) bool) error {
+	for _, value := range values {
+		if !fn(value) {
+			break
+		}
+	}
+	return nil
+}
@@ -31,6 +31,17 @@ const (
 	HideMediaDownloadOptionsFlag ChannelFlag = 1 << 15
 )
 
+var channelFlagNames = map[ChannelFlag]string{
+	PinnedFlag:                   "PINNED",
+	RequireTagFlag:               "REQUIRE_TAG",
+	HideMediaDownloadOptionsFlag: "HIDE_MEDIA_DOWNLOAD_OPTIONS",
+}
+
+// String returns the Discord names of the set flags.
+func (f ChannelFlag) String() string {
+	return flagNames(f, channelFlagNames)
+}
+
 type SortOrderType int
 
 const (
@@ -73,7 +84,7 @@ type Channel struct {
 	ThreadMetadata         *ThreadMetaData        `json:"thread_metadata,omitempty"`
 	ThreadMember           *ThreadMember          `json:"thread_member,omitempty"`
 	AutoArchiveDuration    *int                   `json:"auto_archive_duration,omitempty"`
-	Permissions            *string                `json:"permissions,omitempty"`
+	Permissions            *Bitfield[Permission]  `json:"permissions,omitempty"`
 	Flags                  *Bitfield[ChannelFlag] `json:"flags,omitempty"`
 	TotalMessageSent       *int                   `json:"total_message_sent,omitempty"`
 	AvailableTags          []ForumTag             `json:"available_tags,omitempty"`
@@ -146,10 +157,10 @@ func (t *TypingChannel) startTimeout(user Snowflake) *time.Timer {
 }
 
 type Overwrite struct {
-	ID    Snowflake `json:"id"`
-	Type  int       `json:"type"`
-	Allow string    `json:"allow"`
-	Deny  string    `json:"deny"`
+	ID    Snowflake            `json:"id"`
+	Type  int                  `json:"type"`
+	Allow Bitfield[Permission] `json:"allow"`
+	Deny  Bitfield[Permission] `json:"deny"`
 }
 
 type ThreadMetaData struct {
@@ -158,7 +158,7 @@ func (c *CreateMessageDto) SetAttachments(attachments []structs.Attachment) {
 }
 
 func (c *CreateMessageDto) SetFlags(flags structs.Bitfield[structs.MessageFlag]) error {
-	for _, flag := range flags {
+	for _, flag := range flags.Flags() {
 		if flag != structs.SurpressEmbedsMessageFlag && flag != structs.SurpressNotificationsMessageFlag {
 			return errors.New("can only accept SUPRESS_EMBEDS and SUPRESS_NOTIFICATIONS flags")
 		}
@@ -149,10 +149,14 @@ type ChannelCreateEvent struct {
 
 type ChannelUpdateEvent struct {
 	*structs.Channel
+	// Old is the cached channel before the update, nil when it wasn't cached
+	Old *structs.Channel `json:"-"`
 }
 
 type ChannelDeleteEvent struct {
 	*structs.Channel
+	// Old is the last cached copy of the channel, nil when it wasn't cached
+	Old *structs.Channel `json:"-"`
 }
 
 type ThreadCreateEvent struct {
@@ -162,6 +166,8 @@ type ThreadCreateEvent struct {
 
 type ThreadUpdateEvent struct {
 	*structs.Channel
+	// Old is the cached thread before the update, nil when it wasn't cached
+	Old *structs.Channel `json:"-"`
 }
 
 type ThreadDeleteEvent struct {
@@ -169,6 +175,8 @@ type ThreadDeleteEvent struct {
 	GuildID  structs.Snowflake   `json:"guild_id"`
 	ParentID structs.Snowflake   `json:"parent_id"`
 	Type     structs.ChannelType `json:"type"`
+	// Old is the last cached copy of the thread, nil when it wasn't cached
+	Old *structs.Channel `json:"-"`
 }
 
 type ThreadListSyncEvent struct {
@@ -262,6 +270,8 @@ type GuildMemberAddEvent struct {
 type GuildMemberRemoveEvent struct {
 	GuildID structs.Snowflake `json:"guild_id"`
 	User    structs.User      `json:"user"`
+	// Old is the last cached copy of the member, nil when it wasn't cached
+	Old *structs.GuildMember `json:"-"`
 }
 
 type GuildMemberUpdateEvent struct {
@@ -278,6 +288,8 @@ type GuildMemberUpdateEvent struct {
 	CommunicationDisabledUntil *time.Time                                `json:"communication_disabled_until,omitempty"`
 	Flags                      structs.Bitfield[structs.GuildMemberFlag] `json:"flags"`
 	AvatarDecorationData       *structs.AvatarDecorationData             `json:"avatar_decoration_data,omitempty"`
+	// Old is the cached member before the update, nil when it wasn't cached
+	Old *structs.GuildMember `json:"-"`
 }
 
 type GuildRoleCreateEvent struct {
@@ -288,11 +300,15 @@ type GuildRoleCreateEvent struct {
 type GuildRoleUpdateEvent struct {
 	GuildID structs.Snowflake `json:"guild_id"`
 	Role    structs.Role      `json:"role"`
+	// Old is the cached role before the update, nil when it wasn't cached
+	Old *structs.Role `json:"-"`
 }
 
 type GuildRoleDeleteEvent struct {
 	GuildID structs.Snowflake `json:"guild_id"`
 	RoleID  structs.Snowflake `json:"role_id"`
+	// Old is the last cached copy of the role, nil when it wasn't cached
+	Old *structs.Role `json:"-"`
 }
 
 type GuildScheduledEventCreateEvent struct {
@@ -372,18 +388,24 @@ type MessageUpdateEvent struct {
 	GuildID  *structs.Snowflake   `json:"guild_id,omitempty"`
 	Member   *structs.GuildMember `json:"member,omitempty"`
 	Mentions []MessageCreateUser  `json:"mentions"`
+	// Old is the cached message before the edit, nil when it wasn't cached
+	Old *structs.Message `json:"-"`
 }
 
 type MessageDeleteEvent struct {
 	ID        structs.Snowflake  `json:"id"`
 	ChannelID structs.Snowflake  `json:"channel_id"`
 	GuildID   *structs.Snowflake `json:"guild_id,omitempty"`
+	// Old is the last cached copy of the message, nil when it wasn't cached
+	Old *structs.Message `json:"-"`
 }
 
 type MessageDeleteBulkEvent struct {
 	IDs       []structs.Snowflake `json:"ids"`
 	ChannelID structs.Snowflake   `json:"channel_id"`
 	GuildID   *structs.Snowflake  `json:"guild_id,omitempty"`
+	// Old holds the last cached copy of the deleted messages that were cached
+	Old []structs.Message `json:"-"`
 }
 
 type MessageReactionAddEvent struct {
@@ -536,5 +558,6 @@ type GuildMembersChunk struct {
 	ChunkIndex int                   `json:"chunk_index"`
 	ChunkCount int                   `json:"chunk_count"`
 	NotFound   []structs.Snowflake   `json:"not_found"`
-	Presences  []PresenceUpdateEvent
+	Presences  []PresenceUpdateEvent `json:"presences"`
+	Nonce      *string               `json:"nonce,omitempty"`
 }
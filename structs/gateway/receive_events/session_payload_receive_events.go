@@ -24,7 +24,7 @@ func NewReceiveEvent(eventData payload.SessionPayload) (any, error) {
 		eventData.Data = event
 		return event, nil
 	case gateway.GatewayOpHeartbeat:
-		var event HelloEvent
+		var event HeartbeatEvent
 		if err := json.Unmarshal(jsonData, &event); err != nil {
 			return nil, err
 		}
@@ -286,6 +286,13 @@ func handleDispatchEvent(data []byte, payload payload.SessionPayload) (any, erro
 		}
 		payload.Data = event
 		return event, nil
+	case "GUILD_MEMBERS_CHUNK":
+		var event GuildMembersChunk
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		payload.Data = event
+		return event, nil
 	case "GUILD_ROLE_CREATE":
 		var event GuildRoleCreateEvent
 		if err := json.Unmarshal(data, &event); err != nil {
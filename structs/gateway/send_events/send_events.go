@@ -204,10 +204,10 @@ func (h *HeartbeatEvent) MarshalJSON() ([]byte, error) {
 
 type RequestGuildMembersEvent struct {
 	GuildID   structs.Snowflake   `json:"guild_id"`
-	Query     string              `json:"query"`
+	Query     *string             `json:"query,omitempty"`
 	Limit     int                 `json:"limit"`
 	Presences bool                `json:"presences"`
-	UserIDs   []structs.Snowflake `json:"user_ids"`
+	UserIDs   []structs.Snowflake `json:"user_ids,omitempty"`
 	Nonce     *string             `json:"nonce,omitempty"`
 }
 
@@ -219,8 +219,35 @@ type UpdateVoiceStateEvent struct {
 }
 
 type PresenceUpdateEvent struct {
-	Since      int                `json:"since"`
-	Activities []structs.Activity `json:"activities"`
-	Status     string             `json:"status"`
-	Afk        bool               `json:"afk"`
+	Since      int                    `json:"since"`
+	Activities []structs.Activity     `json:"activities"`
+	Status     structs.UserStatusType `json:"status"`
+	Afk        bool                   `json:"afk"`
+}
+
+// presenceActivity holds the only activity fields a bot is allowed to send.
+type presenceActivity struct {
+	Name  string               `json:"name"`
+	Type  structs.ActivityType `json:"type"`
+	URL   *string              `json:"url,omitempty"`
+	State *string              `json:"state,omitempty"`
+}
+
+func (p PresenceUpdateEvent) MarshalJSON() ([]byte, error) {
+	activities := make([]presenceActivity, 0, len(p.Activities))
+	for _, activity := range p.Activities {
+		activities = append(activities, presenceActivity{
+			Name:  activity.Name,
+			Type:  activity.Type,
+			URL:   activity.URL,
+			State: activity.State,
+		})
+	}
+
+	return json.Marshal(struct {
+		Since      int                    `json:"since"`
+		Activities []presenceActivity     `json:"activities"`
+		Status     structs.UserStatusType `json:"status"`
+		Afk        bool                   `json:"afk"`
+	}{p.Since, activities, p.Status, p.Afk})
 }
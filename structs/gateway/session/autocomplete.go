@@ -0,0 +1,94 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// maxAutocompleteChoices is the most choices Discord accepts in an autocomplete response.
+const maxAutocompleteChoices = 25
+
+// AutocompleteProvider returns the choices for the option the user is typing in.
+// The focused value is what the user has typed so far, the other options filled in so far are available on the context,
+// i.e through `ctx.Option` or `ctx.Bind`. Only the first 25 choices are sent.
+type AutocompleteProvider func(ctx *CommandContext, focused string) ([]structs.ApplicationCommandOptionChoice, error)
+
+// Autocomplete registers a provider for an option of the command path.
+//
+// Example:
+//
+//	router.Autocomplete("music play", "song", func(ctx *session.CommandContext, focused string) ([]structs.ApplicationCommandOptionChoice, error) {
+//	    var choices []structs.ApplicationCommandOptionChoice
+//	    for _, song := range library.Search(focused) {
+//	        choices = append(choices, structs.ApplicationCommandOptionChoice{Name: song.Title, Value: song.ID})
+//	    }
+//	    return choices, nil
+//	})
+func (r *CommandRouter) Autocomplete(path, option string, provider AutocompleteProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[autocompleteKey(path, option)] = provider
+}
+
+// LookupAutocomplete returns the provider registered for the option of the command path.
+func (r *CommandRouter) LookupAutocomplete(path, option string) (AutocompleteProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.providers[autocompleteKey(path, option)]
+	return provider, ok && provider != nil
+}
+
+// autocompleteHandler returns a handler that answers the autocomplete interaction of the context, if a provider is registered for it.
+func (r *CommandRouter) autocompleteHandler(ctx *CommandContext) (CommandHandler, bool) {
+	focused := ctx.Focused()
+	if focused == nil {
+		return nil, false
+	}
+
+	provider, ok := r.LookupAutocomplete(ctx.Path, focused.Name)
+	if !ok {
+		return nil, false
+	}
+
+	return func(ctx *CommandContext) error {
+		value := ""
+		if focused.Value != nil {
+			value = fmt.Sprint(focused.Value)
+		}
+
+		choices, err := provider(ctx, value)
+		if err != nil {
+			return err
+		}
+		return ctx.Suggest(choices)
+	}, true
+}
+
+// Focused returns the option the user is typing in during autocomplete, nil for anything else.
+func (c *CommandContext) Focused() *structs.ApplicationCommandInteractionDataOption {
+	for i := range c.Options {
+		if c.Options[i].Focused != nil && *c.Options[i].Focused {
+			return &c.Options[i]
+		}
+	}
+	return nil
+}
+
+// Suggest answers an autocomplete interaction with the choices, anything past the first 25 is left out.
+func (c *CommandContext) Suggest(choices []structs.ApplicationCommandOptionChoice) error {
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.ApplicationCommandAutocompleteResultInteraction)
+	if err := response.SetChoices(choices); err != nil {
+		return err
+	}
+	return c.respond(response)
+}
+
+func autocompleteKey(path, option string) string {
+	return normalizeCommandPath(path) + "\x00" + option
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
 	"github.com/Carmen-Shannon/simple-discord/structs/dto"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
@@ -40,10 +41,17 @@ type clientSession struct {
 	maxConcurrency *int
 	version        string
 
-	servers       map[string]*structs.Server
+	cache         cache.CacheStore
 	voiceSessions map[string]VoiceSession
 
 	eventHandler *eventHandler
+	limiter      *sendLimiter
+	heartbeat    *heartbeatTracker
+
+	presenceMu      *sync.Mutex
+	presence        *sendevents.PresenceUpdateEvent
+	presenceSentAt  time.Time
+	presencePending bool
 
 	cb func(s ClientSession) error
 
@@ -68,6 +76,11 @@ type ClientSession interface {
 	ResumeSession() error
 	RegisterCommands(commands map[string]CommandFunc)
 	RegisterListeners(listeners map[Listener]CommandFunc)
+	Use(middlewares ...Middleware)
+	SetDispatcher(opts DispatcherOptions)
+	GetDispatcherStats() (DispatcherStats, bool)
+	OnError(handler func(ErrorContext))
+	SetCommandRouter(router *CommandRouter)
 	GetToken() *string
 	SetToken(token string)
 	GetIntents() []structs.Intent
@@ -84,15 +97,25 @@ type ClientSession interface {
 	SetShards(shards int)
 	GetMaxConcurrency() *int
 	SetMaxConcurrency(concurrency int)
-	AddServer(s structs.Server)
+	AddServer(s structs.Server) error
 	GetServers() map[string]*structs.Server
 	SetHeartbeatAck(ack int)
 	GetHeartbeatAck() *int
+	Latency() time.Duration
 	GetCtx() context.Context
 	GetCancel() context.CancelFunc
 	SetResumeUrl(url string)
 	GetResumeUrl() *string
 	GetServerByGuildID(guildID structs.Snowflake) *structs.Server
+	SelfPermissions(channelID structs.Snowflake) (structs.Permission, error)
+	CanSend(channelID structs.Snowflake) bool
+	RequestGuildMembers(ctx context.Context, guildID structs.Snowflake, request GuildMembersRequest) (*GuildMembersResult, error)
+	SetChunkGuilds(chunk bool)
+	SetPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error
+	SetInitialPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error
+	GetPresence() *sendevents.PresenceUpdateEvent
+	GetCache() cache.CacheStore
+	SetCache(c cache.CacheStore)
 	SetCb(cb func(s ClientSession) error)
 	SetEventHandler(handler *eventHandler)
 	GetEventHandler() *eventHandler
@@ -110,7 +133,10 @@ func NewClientSession(version string) ClientSession {
 		mu:             &sync.Mutex{},
 		Session:        NewSession(),
 		eventHandler:   NewEventHandler[eventHandler](),
-		servers:        make(map[string]*structs.Server),
+		limiter:        newSendLimiter(),
+		heartbeat:      newHeartbeatTracker(),
+		presenceMu:     &sync.Mutex{},
+		cache:          cache.New(cache.Options{}),
 		voiceSessions:  make(map[string]VoiceSession),
 		closeGroup:     *structs.NewSyncGroup(),
 		helloReceived:  make(chan struct{}),
@@ -411,7 +437,9 @@ func (s *clientSession) ReconnectSession() error {
 	sess.SetShards(*s.GetShards())
 	sess.SetMaxConcurrency(*s.GetMaxConcurrency())
 	sess.SetEventHandler(s.eventHandler)
+	sess.SetCache(s.GetCache())
 	sess.SetCb(s.cb)
+	s.copyPresence(sess)
 	if err := sess.Dial(false); err != nil {
 		return err
 	}
@@ -442,9 +470,8 @@ func (s *clientSession) ResumeSession() error {
 	sess.SetBotData(*s.GetBotData())
 	sess.SetSequence(*s.GetSequence())
 	sess.SetCb(s.cb)
-	for _, server := range s.GetServers() {
-		sess.AddServer(*server)
-	}
+	sess.SetCache(s.GetCache())
+	s.copyPresence(sess)
 	for _, vs := range s.voiceSessions {
 		sess.AddVoiceSession(*vs.GetGuildID(), vs)
 	}
@@ -477,6 +504,36 @@ func (s *clientSession) RegisterListeners(listeners map[Listener]CommandFunc) {
 	}
 }
 
+func (s *clientSession) Use(middlewares ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandler.Use(middlewares...)
+}
+
+func (s *clientSession) SetDispatcher(opts DispatcherOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandler.SetDispatcher(opts)
+}
+
+func (s *clientSession) GetDispatcherStats() (DispatcherStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.eventHandler.DispatcherStats()
+}
+
+func (s *clientSession) OnError(handler func(ErrorContext)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandler.OnError(handler)
+}
+
+func (s *clientSession) SetCommandRouter(router *CommandRouter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandler.SetCommandRouter(router)
+}
+
 func (s *clientSession) GetToken() *string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -578,16 +635,40 @@ func (s *clientSession) SetMaxConcurrency(concurrency int) {
 	s.maxConcurrency = &concurrency
 }
 
-func (s *clientSession) AddServer(server structs.Server) {
+// AddServer caches the server as handled by the shard of the session.
+func (s *clientSession) AddServer(server structs.Server) error {
+	return s.GetCache().SetServer(shardOf(s), server)
+}
+
+// GetServers returns a snapshot of the servers handled by the shard of the session, keyed by guild ID. Returns nil when the cache store fails.
+func (s *clientSession) GetServers() map[string]*structs.Server {
+	servers, err := cache.Servers(s.GetCache(), shardOf(s))
+	if err != nil {
+		return nil
+	}
+	return servers
+}
+
+// GetCache returns the cache store the session keeps up to date, shared with the other shards when the session belongs to a bot.
+func (s *clientSession) GetCache() cache.CacheStore {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.servers[server.ID.ToString()] = &server
+	return s.cache
 }
 
-func (s *clientSession) GetServers() map[string]*structs.Server {
+// SetCache replaces the cache store of the session, this has to happen before the session dials the gateway.
+func (s *clientSession) SetCache(c cache.CacheStore) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.servers
+	s.cache = c
+}
+
+// shardOf returns the shard of the session, 0 before it is set.
+func shardOf(s ClientSession) int {
+	if shard := s.GetShard(); shard != nil {
+		return *shard
+	}
+	return 0
 }
 
 func (s *clientSession) SetHeartbeatAck(ack int) {
@@ -656,17 +737,57 @@ func (s *clientSession) GetResumeUrl() *string {
 	return s.resumeUrl
 }
 
+// GetServerByGuildID returns a snapshot of the cached server, nil if the guild isn't cached or the cache store fails.
 func (s *clientSession) GetServerByGuildID(guildID structs.Snowflake) *structs.Server {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	server, err := s.GetCache().GetServer(guildID)
+	if err != nil {
+		return nil
+	}
+	return server
+}
 
-	for _, guild := range s.servers {
-		if guild.ID.Equals(guildID) {
-			return guild
-		}
+// SelfPermissions returns the permissions of the bot in the guild channel or thread, computed from the cache.
+// Returns cache.ErrNotCached when the channel, its guild or the member of the bot isn't cached.
+func (s *clientSession) SelfPermissions(channelID structs.Snowflake) (structs.Permission, error) {
+	botData := s.GetBotData()
+	if botData == nil || botData.UserDetails == nil {
+		return 0, errors.New("session is not ready")
 	}
 
-	return nil
+	c := s.GetCache()
+	channel, err := c.GetChannel(channelID)
+	if err != nil {
+		return 0, err
+	} else if channel == nil {
+		return 0, cache.ErrNotCached
+	} else if channel.GuildID == nil {
+		return 0, errors.New("channel is not in a guild")
+	}
+	return cache.Permissions(c, *channel.GuildID, botData.UserDetails.ID, &channelID)
+}
+
+// CanSend reports whether the bot can send messages in the channel or thread, false when it can't be told from the cache.
+// Direct messages are always allowed, threads need SEND_MESSAGES_IN_THREADS and locked threads MANAGE_THREADS.
+func (s *clientSession) CanSend(channelID structs.Snowflake) bool {
+	channel, err := s.GetCache().GetChannel(channelID)
+	if err != nil || channel == nil {
+		return false
+	}
+	if channel.GuildID == nil {
+		return true
+	}
+
+	permissions, err := s.SelfPermissions(channelID)
+	if err != nil {
+		return false
+	}
+	if channel.ThreadMetadata != nil {
+		if channel.ThreadMetadata.IsLocked && !permissions.Has(structs.ManageThreads) {
+			return false
+		}
+		return permissions.Has(structs.ViewChannel | structs.SendMessagesInThreads)
+	}
+	return permissions.Has(structs.ViewChannel | structs.SendMessages)
 }
 
 func (s *clientSession) SetCb(cb func(s ClientSession) error) {
@@ -0,0 +1,289 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+)
+
+var (
+	ErrCollectorStopped = errors.New("collector stopped")
+	ErrCollectorTimeout = errors.New("collector timed out")
+	ErrCollectorIdle    = errors.New("collector idle timeout")
+	ErrSessionClosed    = errors.New("session closed")
+)
+
+// collectorBuffer is the amount of events a collector can have queued before new events are dropped.
+const collectorBuffer = 100
+
+// CollectorOptions controls when a Collector stops collecting.
+// A zero value for any of the fields disables that limit.
+type CollectorOptions struct {
+	// Max is the amount of events to collect before stopping.
+	Max int
+	// IdleTimeout stops the collector if no matching event was collected within the duration.
+	IdleTimeout time.Duration
+	// Timeout is the total lifetime of the collector.
+	Timeout time.Duration
+}
+
+// Collector collects gateway events of type T that match a filter, until one of its limits is reached,
+// the context is done, or the session it was created on disconnects.
+//
+// T is the event type found in `payload.SessionPayload.Data`, i.e `receiveevents.MessageCreateEvent`.
+// Using `payload.SessionPayload` as T will collect the raw payloads instead.
+type Collector[T any] struct {
+	eh     *eventHandler
+	id     uint64
+	filter func(T) bool
+	opts   CollectorOptions
+
+	in   chan T
+	out  chan T
+	stop chan struct{}
+	done chan struct{}
+
+	mu       *sync.Mutex
+	items    []T
+	err      error
+	stopOnce *sync.Once
+}
+
+// NewCollector creates a new Collector on the given session and starts collecting right away.
+// The collector is cleaned up automatically once it finishes, so there is no need to call `Stop` unless you want to end it early.
+//
+// Parameters:
+//   - ctx: Stops the collector when done.
+//   - s: The session to collect events from, events from other shards are not seen.
+//   - filter: Decides if an event should be collected, a nil filter collects every event of type T.
+//   - opts: The limits for the collector.
+//
+// Example:
+//
+//	// collect reactions on a message for 5 minutes
+//	c := session.NewCollector(ctx, sess, func(e receiveevents.MessageReactionAddEvent) bool {
+//	    return e.MessageID.Equals(msg.ID)
+//	}, session.CollectorOptions{Timeout: 5 * time.Minute})
+//	reactions, err := c.Wait()
+func NewCollector[T any](ctx context.Context, s ClientSession, filter func(T) bool, opts CollectorOptions) *Collector[T] {
+	c := &Collector[T]{
+		eh:       s.GetEventHandler(),
+		filter:   filter,
+		opts:     opts,
+		in:       make(chan T, collectorBuffer),
+		out:      make(chan T, collectorBuffer),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		mu:       &sync.Mutex{},
+		stopOnce: &sync.Once{},
+	}
+
+	// the ID has to be set before the collector is registered, offer reads it as soon as events come in
+	c.id = c.eh.nextCollectorID()
+	c.eh.addCollector(c.id, c.offer)
+	go c.run(ctx, s.GetCtx())
+	return c
+}
+
+// WaitFor blocks until an event of type T matching the predicate is received on the session, or the context is done.
+// Use a context with a deadline to wait for a limited amount of time.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+//	defer cancel()
+//	reply, err := session.WaitFor(ctx, sess, func(e receiveevents.MessageCreateEvent) bool {
+//	    return e.Author.ID.Equals(userID) && e.ChannelID.Equals(channelID)
+//	})
+func WaitFor[T any](ctx context.Context, s ClientSession, predicate func(T) bool) (T, error) {
+	c := NewCollector(ctx, s, predicate, CollectorOptions{Max: 1})
+	items, err := c.Wait()
+	if len(items) > 0 {
+		return items[0], nil
+	}
+
+	var zero T
+	if errors.Is(err, ErrCollectorStopped) && ctx.Err() != nil {
+		return zero, ctx.Err()
+	}
+	return zero, err
+}
+
+// Events returns a channel receiving every collected event, it is closed once the collector is finished.
+// Events that don't fit in the channel buffer are still collected and returned by `Wait`.
+func (c *Collector[T]) Events() <-chan T {
+	return c.out
+}
+
+// Done returns a channel that is closed once the collector is finished.
+func (c *Collector[T]) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the collector is finished and returns the collected events along with the reason it stopped.
+// Reaching `Max` is not considered an error.
+func (c *Collector[T]) Wait() ([]T, error) {
+	<-c.done
+	return c.Collected(), c.Err()
+}
+
+// Collected returns a copy of the events collected so far.
+func (c *Collector[T]) Collected() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	items := make([]T, len(c.items))
+	copy(items, c.items)
+	return items
+}
+
+// Err returns the reason the collector stopped, or nil if it is still running or reached `Max`.
+func (c *Collector[T]) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Stop ends the collector early.
+func (c *Collector[T]) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// offer is called by the event handler for every dispatched event, it must never block.
+// The filter runs here so only the matching events take up room in the buffer.
+func (c *Collector[T]) offer(p payload.SessionPayload) {
+	var event T
+	if v, ok := any(p).(T); ok {
+		event = v
+	} else if v, ok := p.Data.(T); ok {
+		event = v
+	} else {
+		return
+	}
+	if !c.matches(event) {
+		return
+	}
+
+	select {
+	case c.in <- event:
+	default:
+		log.Printf("collector %d is full, dropping %s event", c.id, eventName(p))
+	}
+}
+
+func (c *Collector[T]) run(ctx, sessionCtx context.Context) {
+	defer close(c.done)
+	defer close(c.out)
+	defer c.eh.removeCollector(c.id)
+
+	var timeout, idle <-chan time.Time
+	if c.opts.Timeout > 0 {
+		timer := time.NewTimer(c.opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var idleTimer *time.Timer
+	if c.opts.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(c.opts.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.finish(ErrCollectorStopped)
+			return
+		case <-sessionCtx.Done():
+			c.finish(ErrSessionClosed)
+			return
+		case <-c.stop:
+			c.finish(ErrCollectorStopped)
+			return
+		case <-timeout:
+			c.finish(ErrCollectorTimeout)
+			return
+		case <-idle:
+			c.finish(ErrCollectorIdle)
+			return
+		case event := <-c.in:
+			c.mu.Lock()
+			c.items = append(c.items, event)
+			count := len(c.items)
+			c.mu.Unlock()
+
+			select {
+			case c.out <- event:
+			default:
+			}
+
+			if c.opts.Max > 0 && count >= c.opts.Max {
+				return
+			}
+
+			if idleTimer != nil {
+				if !idleTimer.Stop() {
+					<-idleTimer.C
+				}
+				idleTimer.Reset(c.opts.IdleTimeout)
+			}
+		}
+	}
+}
+
+// matches runs the filter, a filter that panics doesn't match so it can't take down the event handler.
+func (c *Collector[T]) matches(event T) (ok bool) {
+	if c.filter == nil {
+		return true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("collector %d filter panicked: %v", c.id, r)
+			ok = false
+		}
+	}()
+	return c.filter(event)
+}
+
+func (c *Collector[T]) finish(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// nextCollectorID returns a new ID to register a collector with.
+func (e *eventHandler) nextCollectorID() uint64 {
+	e.colMu.Lock()
+	defer e.colMu.Unlock()
+	e.collectorID++
+	return e.collectorID
+}
+
+// addCollector registers a collector callback under the ID.
+func (e *eventHandler) addCollector(id uint64, offer func(payload.SessionPayload)) {
+	e.colMu.Lock()
+	defer e.colMu.Unlock()
+	e.collectors[id] = offer
+}
+
+func (e *eventHandler) removeCollector(id uint64) {
+	e.colMu.Lock()
+	defer e.colMu.Unlock()
+	delete(e.collectors, id)
+}
+
+// feedCollectors hands the payload to every active collector.
+func (e *eventHandler) feedCollectors(p payload.SessionPayload) {
+	e.colMu.Lock()
+	defer e.colMu.Unlock()
+	for _, offer := range e.collectors {
+		offer(p)
+	}
+}
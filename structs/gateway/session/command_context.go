@@ -0,0 +1,349 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util/i18n"
+)
+
+// CommandContext carries everything a routed command needs to read its options and respond.
+type CommandContext struct {
+	// Session is the session the command was received on.
+	Session ClientSession
+	// Payload is the raw payload the command was received in.
+	Payload payload.SessionPayload
+	// Interaction is the interaction that triggered the command.
+	Interaction *structs.Interaction
+	// Path is the full command path, i.e `config channel set`.
+	Path string
+	// Options are the options of the innermost subcommand.
+	Options []structs.ApplicationCommandInteractionDataOption
+	// Params are the parts of the custom ID captured by a component pattern.
+	Params map[string]string
+	// Message is the message that invoked a text command, nil for interactions.
+	Message *structs.Message
+	// Args are the arguments of a text command, after the command name.
+	Args []string
+
+	respond      func(response structs.InteractionResponseOptions) error
+	ephemeral    func()
+	translations *i18n.Bundle
+
+	// text commands only
+	guildID  *structs.Snowflake
+	member   *structs.GuildMember
+	mentions []receiveevents.MessageCreateUser
+	prefix   string
+	rawArgs  string
+}
+
+// OptionError is returned by `CommandContext.Bind` when an option is missing or has an invalid value.
+// The message is meant to be shown to the user that invoked the command.
+type OptionError struct {
+	Option string
+	Reason string
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("the `%s` option %s", e.Option, e.Reason)
+}
+
+var (
+	snowflakeType  = reflect.TypeOf(structs.Snowflake{})
+	userType       = reflect.TypeOf(structs.User{})
+	memberType     = reflect.TypeOf(structs.GuildMember{})
+	roleType       = reflect.TypeOf(structs.Role{})
+	channelType    = reflect.TypeOf(structs.Channel{})
+	attachmentType = reflect.TypeOf(structs.Attachment{})
+)
+
+// NewInteractionContext creates the CommandContext for an interaction received on the gateway, responses are sent through the session.
+func NewInteractionContext(s ClientSession, p payload.SessionPayload, interaction *structs.Interaction) *CommandContext {
+	path, options := commandPath(interaction.Data)
+	return &CommandContext{
+		Session:     s,
+		Payload:     p,
+		Interaction: interaction,
+		Path:        path,
+		Options:     options,
+		respond: func(response structs.InteractionResponseOptions) error {
+			return s.Reply(response, interaction)
+		},
+	}
+}
+
+// Respond sends the interaction response as is.
+func (c *CommandContext) Respond(response structs.InteractionResponseOptions) error {
+	return c.respond(response)
+}
+
+// Reply responds to the command with a message.
+func (c *CommandContext) Reply(content string) error {
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.ChannelMessageWithSourceInteraction)
+	response.SetContent(content)
+	return c.respond(response)
+}
+
+// ReplyEphemeral responds to the command with a message only the invoking user can see.
+func (c *CommandContext) ReplyEphemeral(content string) error {
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.ChannelMessageWithSourceInteraction)
+	response.SetContent(content)
+	if err := response.SetFlags(structs.NewBitfield(structs.EphemeralMessageFlag)); err != nil {
+		return err
+	}
+	return c.respond(response)
+}
+
+// Defer acknowledges the command, showing a loading state to the user until a follow up is sent.
+func (c *CommandContext) Defer(ephemeral bool) error {
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.DeferredChannelMessageWithSourceInteraction)
+	if ephemeral {
+		if err := response.SetFlags(structs.NewBitfield(structs.EphemeralMessageFlag)); err != nil {
+			return err
+		}
+	}
+	return c.respond(response)
+}
+
+// PreferEphemeral asks for the response sent on behalf of a slow handler to be visible only to the invoking user.
+// It only matters to an InteractionServer, which defers the command when the handler doesn't respond in time,
+// and has to be called before that happens. To control the deferral directly, call `Defer` instead.
+func (c *CommandContext) PreferEphemeral() {
+	if c.ephemeral != nil {
+		c.ephemeral()
+	}
+}
+
+// Author returns the user that invoked the command.
+func (c *CommandContext) Author() *structs.User {
+	if c.Interaction == nil {
+		if c.Message != nil {
+			return &c.Message.Author
+		}
+		return nil
+	}
+	if c.Interaction.Member != nil && c.Interaction.Member.User != nil {
+		return c.Interaction.Member.User
+	}
+	return c.Interaction.User
+}
+
+// Member returns the guild member that invoked the command, nil in direct messages.
+func (c *CommandContext) Member() *structs.GuildMember {
+	if c.Interaction == nil {
+		return c.member
+	}
+	return c.Interaction.Member
+}
+
+// GuildID returns the guild the command was invoked in, nil in direct messages.
+func (c *CommandContext) GuildID() *structs.Snowflake {
+	if c.Interaction == nil {
+		return c.guildID
+	}
+	return c.Interaction.GuildID
+}
+
+// ChannelID returns the channel the command was invoked in.
+func (c *CommandContext) ChannelID() *structs.Snowflake {
+	if c.Interaction == nil {
+		if c.Message != nil {
+			return &c.Message.ChannelID
+		}
+		return nil
+	}
+	return c.Interaction.ChannelID
+}
+
+// Option returns the option with the given name, nil if the user didn't provide it.
+func (c *CommandContext) Option(name string) *structs.ApplicationCommandInteractionDataOption {
+	for i := range c.Options {
+		if c.Options[i].Name == name {
+			return &c.Options[i]
+		}
+	}
+	return nil
+}
+
+// Bind copies the command options into the struct pointed to by dst, using the `discord` tag of each field.
+// The tag holds the option name, optionally followed by `required`, i.e `discord:"user,required"`. Fields without a tag are ignored.
+//
+// Supported field types are strings, booleans, integers, floats, `structs.Snowflake`, and the resolved
+// `structs.User`, `structs.GuildMember`, `structs.Role`, `structs.Channel` and `structs.Attachment`.
+// Any of these can be a pointer, which is left nil when the option was not provided.
+//
+// A missing required option or an invalid value returns an *OptionError.
+func (c *CommandContext) Bind(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a pointer to a struct")
+	}
+	v = v.Elem()
+
+	var resolved *structs.ResolvedData
+	if c.Interaction != nil && c.Interaction.Data != nil {
+		resolved = c.Interaction.Data.Resolved
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("discord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		required := flags == "required"
+
+		option := c.Option(name)
+		if option == nil || option.Value == nil {
+			if required {
+				return &OptionError{Option: name, Reason: "is required"}
+			}
+			continue
+		}
+
+		value, err := optionValue(*option, resolved, field.Type)
+		if err != nil {
+			return err
+		}
+		v.Field(i).Set(value)
+	}
+	return nil
+}
+
+// optionValue converts the option value to the type t, looking up entities in the resolved data.
+func optionValue(option structs.ApplicationCommandInteractionDataOption, resolved *structs.ResolvedData, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		value, err := optionValue(option, resolved, t.Elem())
+		if err != nil {
+			return value, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(value)
+		return ptr, nil
+	}
+
+	var entity any
+	switch t {
+	case snowflakeType, userType, memberType, roleType, channelType, attachmentType:
+		id, err := optionSnowflake(option)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		switch t {
+		case snowflakeType:
+			return reflect.ValueOf(*id), nil
+		case userType:
+			if user := resolved.GetUser(*id); user != nil {
+				entity = *user
+			}
+		case memberType:
+			if member := resolved.GetMember(*id); member != nil {
+				entity = *member
+			}
+		case roleType:
+			if role := resolved.GetRole(*id); role != nil {
+				entity = *role
+			}
+		case channelType:
+			if channel := resolved.GetChannel(*id); channel != nil {
+				entity = *channel
+			}
+		case attachmentType:
+			if attachment := resolved.GetAttachment(*id); attachment != nil {
+				entity = *attachment
+			}
+		}
+
+		if entity == nil {
+			return reflect.Value{}, &OptionError{Option: option.Name, Reason: "refers to something I couldn't find"}
+		}
+		return reflect.ValueOf(entity), nil
+	}
+
+	value := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		if s, ok := option.Value.(string); ok {
+			value.SetString(s)
+		} else {
+			value.SetString(fmt.Sprint(option.Value))
+		}
+	case reflect.Bool:
+		b, ok := option.Value.(bool)
+		if !ok {
+			return value, &OptionError{Option: option.Name, Reason: "must be true or false"}
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := optionNumber(option.Value)
+		if err != nil || n != math.Trunc(n) || value.OverflowInt(int64(n)) {
+			return value, &OptionError{Option: option.Name, Reason: "must be a whole number"}
+		}
+		value.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := optionNumber(option.Value)
+		if err != nil || n < 0 || n != math.Trunc(n) || value.OverflowUint(uint64(n)) {
+			return value, &OptionError{Option: option.Name, Reason: "must be a positive whole number"}
+		}
+		value.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, err := optionNumber(option.Value)
+		if err != nil {
+			return value, &OptionError{Option: option.Name, Reason: "must be a number"}
+		}
+		value.SetFloat(n)
+	default:
+		return value, fmt.Errorf("unsupported option field type %s for option %s", t, option.Name)
+	}
+	return value, nil
+}
+
+func optionNumber(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("unexpected number type %T", value)
+	}
+}
+
+func optionSnowflake(option structs.ApplicationCommandInteractionDataOption) (*structs.Snowflake, error) {
+	var raw string
+	switch v := option.Value.(type) {
+	case string:
+		raw = v
+	case float64:
+		raw = strconv.FormatFloat(v, 'f', 0, 64)
+	default:
+		raw = fmt.Sprint(v)
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, &OptionError{Option: option.Name, Reason: "must be a valid ID"}
+	}
+	return structs.NewSnowflake(id), nil
+}
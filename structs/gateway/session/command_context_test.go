@@ -0,0 +1,167 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+)
+
+// commandContext decodes the interaction the way it arrives from Discord and creates its CommandContext.
+func commandContext(t *testing.T, interaction map[string]any) *CommandContext {
+	t.Helper()
+	data, err := json.Marshal(interaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded structs.Interaction
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	ctx, _ := newHTTPInteractionContext(payload.SessionPayload{}, &decoded)
+	return ctx
+}
+
+func commandWithOptions(options ...map[string]any) map[string]any {
+	return map[string]any{
+		"type": structs.ApplicationCommandInteraction,
+		"data": map[string]any{
+			"id":      "1",
+			"name":    "ban",
+			"type":    structs.ChatInputCommand,
+			"options": options,
+			"resolved": map[string]any{
+				"users": map[string]any{"42": map[string]any{"id": "42", "username": "wumpus"}},
+				"roles": map[string]any{"7": map[string]any{"id": "7", "name": "mods"}},
+			},
+		},
+	}
+}
+
+func TestBind(t *testing.T) {
+	type args struct {
+		User    structs.User      `discord:"user,required"`
+		UserID  structs.Snowflake `discord:"user"`
+		Role    *structs.Role     `discord:"role"`
+		Days    int               `discord:"days"`
+		Ratio   float64           `discord:"ratio"`
+		Reason  string            `discord:"reason"`
+		Silent  bool              `discord:"silent"`
+		Missing *string           `discord:"missing"`
+		Ignored string
+	}
+
+	ctx := commandContext(t, commandWithOptions(
+		map[string]any{"name": "user", "type": structs.UserOptionType, "value": "42"},
+		map[string]any{"name": "role", "type": structs.RoleOptionType, "value": "7"},
+		map[string]any{"name": "days", "type": structs.IntegerOptionType, "value": 7},
+		map[string]any{"name": "ratio", "type": structs.NumberOptionType, "value": 0.5},
+		map[string]any{"name": "reason", "type": structs.StringOptionType, "value": "spam"},
+		map[string]any{"name": "silent", "type": structs.BooleanOptionType, "value": true},
+	))
+
+	var got args
+	if err := ctx.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.User.Username != "wumpus" || got.UserID.ID != 42 {
+		t.Fatalf("user = %+v with ID %v, want wumpus with ID 42", got.User, got.UserID)
+	}
+	if got.Role == nil || got.Role.Name != "mods" {
+		t.Fatalf("role = %+v, want mods", got.Role)
+	}
+	if got.Days != 7 || got.Ratio != 0.5 || got.Reason != "spam" || !got.Silent {
+		t.Fatalf("bound %+v, want 7 days, a ratio of 0.5, the reason spam and silent", got)
+	}
+	if got.Missing != nil || got.Ignored != "" {
+		t.Fatalf("bound %+v, want the missing and untagged fields left alone", got)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dst     any
+		option  map[string]any
+		wantOpt string
+	}{
+		{
+			name: "missing required option",
+			dst: &struct {
+				Reason string `discord:"reason,required"`
+			}{},
+			wantOpt: "reason",
+		},
+		{
+			name: "fraction for an integer",
+			dst: &struct {
+				Days int `discord:"days"`
+			}{},
+			option:  map[string]any{"name": "days", "type": structs.NumberOptionType, "value": 1.5},
+			wantOpt: "days",
+		},
+		{
+			name: "integer overflow",
+			dst: &struct {
+				Days int8 `discord:"days"`
+			}{},
+			option:  map[string]any{"name": "days", "type": structs.IntegerOptionType, "value": 300},
+			wantOpt: "days",
+		},
+		{
+			name: "negative unsigned integer",
+			dst: &struct {
+				Count uint `discord:"count"`
+			}{},
+			option:  map[string]any{"name": "count", "type": structs.IntegerOptionType, "value": -1},
+			wantOpt: "count",
+		},
+		{
+			name: "unresolved user",
+			dst: &struct {
+				User structs.User `discord:"user"`
+			}{},
+			option:  map[string]any{"name": "user", "type": structs.UserOptionType, "value": "99"},
+			wantOpt: "user",
+		},
+		{
+			name: "invalid ID",
+			dst: &struct {
+				ID structs.Snowflake `discord:"id"`
+			}{},
+			option:  map[string]any{"name": "id", "type": structs.StringOptionType, "value": "abc"},
+			wantOpt: "id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []map[string]any
+			if tt.option != nil {
+				options = append(options, tt.option)
+			}
+			ctx := commandContext(t, commandWithOptions(options...))
+
+			var optionErr *OptionError
+			if err := ctx.Bind(tt.dst); !errors.As(err, &optionErr) {
+				t.Fatalf("Bind() = %v, want an *OptionError", err)
+			}
+			if optionErr.Option != tt.wantOpt {
+				t.Fatalf("option = %s, want %s", optionErr.Option, tt.wantOpt)
+			}
+		})
+	}
+}
+
+func TestBindDestination(t *testing.T) {
+	ctx := commandContext(t, commandWithOptions())
+	var notStruct string
+	for _, dst := range []any{nil, struct{}{}, &notStruct} {
+		if err := ctx.Bind(dst); err == nil {
+			t.Fatalf("Bind(%T) accepted a destination that isn't a pointer to a struct", dst)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/util/i18n"
+)
+
+var ErrUnknownCommand = errors.New("no route for command")
+
+// CommandHandler handles a command routed by a CommandRouter.
+type CommandHandler func(ctx *CommandContext) error
+
+// CommandRouter routes application commands to handlers by their full path, i.e `ban`, `config set` or `config channel set`.
+// A single router can be shared by every shard, it is safe for concurrent use.
+type CommandRouter struct {
+	mu           *sync.RWMutex
+	routes       map[string]CommandHandler
+	contextMenus map[string]CommandHandler
+	providers    map[string]AutocompleteProvider
+	components   []*customIDPattern
+	modals       []*customIDPattern
+	commands     []Command
+	translations *i18n.Bundle
+}
+
+func NewCommandRouter() *CommandRouter {
+	return &CommandRouter{
+		mu:           &sync.RWMutex{},
+		routes:       make(map[string]CommandHandler),
+		contextMenus: make(map[string]CommandHandler),
+		providers:    make(map[string]AutocompleteProvider),
+	}
+}
+
+// Handle registers a handler for the command path.
+// The path is the command name followed by the subcommand group and subcommand, if any, separated by spaces.
+//
+// Example:
+//
+//	router.Handle("ping", pingHandler)
+//	router.Handle("config channel set", setChannelHandler)
+func (r *CommandRouter) Handle(path string, handler CommandHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[normalizeCommandPath(path)] = handler
+}
+
+// Lookup returns the handler registered for the command path.
+func (r *CommandRouter) Lookup(path string) (CommandHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.routes[normalizeCommandPath(path)]
+	return handler, ok && handler != nil
+}
+
+// Dispatch runs the handler registered for the context's command path, returning ErrUnknownCommand if there is none.
+func (r *CommandRouter) Dispatch(ctx *CommandContext) error {
+	handler, ok := r.Lookup(ctx.Path)
+	if !ok {
+		return ErrUnknownCommand
+	}
+	return handler(ctx)
+}
+
+// route finds the handler for the interaction of the context, filling in the custom ID parameters for components and modals.
+func (r *CommandRouter) route(ctx *CommandContext) (CommandHandler, bool) {
+	interaction := ctx.Interaction
+	if interaction == nil || interaction.Data == nil {
+		return nil, false
+	}
+
+	var handler CommandHandler
+	var ok bool
+	switch interaction.Type {
+	case structs.ApplicationCommandInteraction:
+		if isContextMenu(interaction.Data) {
+			handler, ok = r.LookupContextMenu(structs.ApplicationCommandType(interaction.Data.Type), interaction.Data.Name)
+		} else {
+			handler, ok = r.Lookup(ctx.Path)
+		}
+	case structs.ApplicationCommandAutocompleteInteraction:
+		handler, ok = r.autocompleteHandler(ctx)
+	case structs.MessageComponentInteraction:
+		handler, ctx.Params, ok = r.LookupComponent(ctx.CustomID())
+	case structs.ModalSubmitInteraction:
+		handler, ctx.Params, ok = r.LookupModal(ctx.CustomID())
+	}
+	return handler, ok
+}
+
+// Handle registers a handler for the command path that receives the command options bound into T.
+// T must be a struct, see `CommandContext.Bind` for the supported fields and tags.
+// When the options fail validation the user gets an ephemeral reply explaining what was wrong, and the handler is not called.
+//
+// Example:
+//
+//	type banOptions struct {
+//	    Member *structs.GuildMember `discord:"user,required"`
+//	    Reason string               `discord:"reason"`
+//	    Days   *int                 `discord:"delete_days"`
+//	}
+//
+//	session.Handle(router, "mod ban", func(ctx *session.CommandContext, opts banOptions) error {
+//	    return ctx.Reply("banned " + opts.Member.User.Username)
+//	})
+func Handle[T any](r *CommandRouter, path string, handler func(ctx *CommandContext, options T) error) {
+	r.Handle(path, func(ctx *CommandContext) error {
+		var options T
+		if err := ctx.Bind(&options); err != nil {
+			var optionErr *OptionError
+			if errors.As(err, &optionErr) {
+				return ctx.ReplyEphemeral("Sorry, I couldn't run that command: " + optionErr.Error() + ".")
+			}
+			return err
+		}
+		return handler(ctx, options)
+	})
+}
+
+// SetCommandRouter routes application command interactions through the router.
+// Interactions the router has no route for still fall through to the handlers registered with `RegisterCommands`.
+func (e *eventHandler) SetCommandRouter(router *CommandRouter) {
+	e.routerMu.Lock()
+	defer e.routerMu.Unlock()
+	e.router = router
+}
+
+func (e *eventHandler) getCommandRouter() *CommandRouter {
+	e.routerMu.RLock()
+	defer e.routerMu.RUnlock()
+	return e.router
+}
+
+// commandPath walks the subcommand groups and subcommands of the interaction data,
+// returning the full command path along with the options of the innermost command.
+func commandPath(data *structs.InteractionData) (string, []structs.ApplicationCommandInteractionDataOption) {
+	if data == nil {
+		return "", nil
+	}
+
+	path := []string{data.Name}
+	options := data.Options
+	for len(options) == 1 && (options[0].Type == structs.SubCommandGroupOptionType || options[0].Type == structs.SubCommandOptionType) {
+		path = append(path, options[0].Name)
+		options = options[0].Options
+	}
+	return strings.Join(path, " "), options
+}
+
+func normalizeCommandPath(path string) string {
+	return strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(path), "/")), " ")
+}
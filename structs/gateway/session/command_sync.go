@@ -0,0 +1,509 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/dto"
+	"github.com/Carmen-Shannon/simple-discord/util"
+	"github.com/Carmen-Shannon/simple-discord/util/i18n"
+	requestutil "github.com/Carmen-Shannon/simple-discord/util/request_util"
+)
+
+// Command declares an application command together with the handlers that run it.
+// Register it on a CommandRouter to route it, and use `SyncCommands` to push it to Discord.
+type Command struct {
+	Name                     string
+	NameLocalizations        map[string]string
+	Description              string
+	DescriptionLocalizations map[string]string
+	// Type defaults to a slash command.
+	Type    structs.ApplicationCommandType
+	Options []structs.ApplicationCommandOption
+	// DefaultMemberPermissions limits the command to members with these permissions, nil allows everyone.
+	DefaultMemberPermissions *structs.Bitfield[structs.Permission]
+	Contexts                 []structs.IntegrationContextType
+	IntegrationTypes         []structs.IntegrationType
+	NSFW                     bool
+	// GuildIDs registers the command in these guilds only, the command is global when empty.
+	GuildIDs []structs.Snowflake
+
+	// Handler runs the command when it has no subcommands.
+	Handler CommandHandler
+	// Subcommands maps the subcommand path, i.e `set` or `channel set`, to its handler.
+	Subcommands map[string]CommandHandler
+	// Autocomplete maps an option name, prefixed with its subcommand path if any, i.e `query` or `channel set name`, to its provider.
+	Autocomplete map[string]AutocompleteProvider
+	// UserHandler runs a user context-menu command, the type defaults to `structs.UserCommand` when set.
+	UserHandler UserCommandHandler
+	// MessageHandler runs a message context-menu command, the type defaults to `structs.MessageCommand` when set.
+	MessageHandler MessageCommandHandler
+	// Guards run before every handler of the command, see `Guards`.
+	Guards *Guards
+}
+
+// SyncAction is the change `SyncCommands` makes to a single command.
+type SyncAction string
+
+const (
+	SyncCreate SyncAction = "create"
+	SyncUpdate SyncAction = "update"
+	SyncDelete SyncAction = "delete"
+)
+
+// SyncChange is a single planned change to the commands registered with Discord.
+type SyncChange struct {
+	Action SyncAction
+	Name   string
+	Type   structs.ApplicationCommandType
+	// GuildID is nil for global commands.
+	GuildID *structs.Snowflake
+	// CommandID is the ID of the existing command, nil for creates.
+	CommandID *structs.Snowflake
+
+	command *Command
+}
+
+func (c SyncChange) String() string {
+	scope := "global"
+	if c.GuildID != nil {
+		scope = "guild " + c.GuildID.ToString()
+	}
+	return fmt.Sprintf("%s %s command %q", c.Action, scope, c.Name)
+}
+
+// SyncPlan lists the changes `SyncCommands` made, or would make in dry-run mode.
+type SyncPlan struct {
+	Changes []SyncChange
+}
+
+func (p *SyncPlan) String() string {
+	if len(p.Changes) == 0 {
+		return "commands are up to date"
+	}
+
+	lines := make([]string, 0, len(p.Changes))
+	for _, change := range p.Changes {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SyncOptions controls how `SyncCommands` applies the changes.
+type SyncOptions struct {
+	// DryRun prints the planned changes without pushing anything to Discord.
+	DryRun bool
+	// KeepUnknown leaves commands that exist on Discord but aren't declared locally alone, instead of deleting them.
+	KeepUnknown bool
+	// Output is where the dry-run plan is printed, defaults to standard output.
+	Output io.Writer
+	// Translations fill in the localizations of the commands, `CommandRouter.Sync` uses the translations of the router when nil.
+	Translations *i18n.Bundle
+}
+
+// commandPayload is the part of a command that is compared against Discord, with the JSON shape the API uses.
+type commandPayload struct {
+	Name                     string                                `json:"name"`
+	NameLocalizations        map[string]string                     `json:"name_localizations,omitempty"`
+	Description              string                                `json:"description,omitempty"`
+	DescriptionLocalizations map[string]string                     `json:"description_localizations,omitempty"`
+	Options                  []structs.ApplicationCommandOption    `json:"options,omitempty"`
+	DefaultMemberPermissions *structs.Bitfield[structs.Permission] `json:"default_member_permissions,omitempty"`
+	Contexts                 []structs.IntegrationContextType      `json:"contexts,omitempty"`
+	IntegrationTypes         []structs.IntegrationType             `json:"integration_types,omitempty"`
+	NSFW                     bool                                  `json:"nsfw,omitempty"`
+	Type                     structs.ApplicationCommandType        `json:"type"`
+}
+
+// Register declares the commands on the router, routing each command and subcommand to its handler.
+// The declared commands are what `SyncCommands` pushes to Discord.
+func (r *CommandRouter) Register(commands ...Command) error {
+	for _, command := range commands {
+		if command.Name == "" {
+			return errors.New("command name is required")
+		}
+		if command.Type == 0 {
+			switch {
+			case command.UserHandler != nil:
+				command.Type = structs.UserCommand
+			case command.MessageHandler != nil:
+				command.Type = structs.MessageCommand
+			default:
+				command.Type = structs.ChatInputCommand
+			}
+		}
+		if (command.UserHandler != nil && command.Type != structs.UserCommand) || (command.MessageHandler != nil && command.Type != structs.MessageCommand) {
+			return fmt.Errorf("command %q has a context-menu handler that doesn't match its type", command.Name)
+		}
+
+		r.mu.Lock()
+		r.commands = append(r.commands, command)
+		r.mu.Unlock()
+
+		guard := func(handler CommandHandler) CommandHandler {
+			if command.Guards == nil {
+				return handler
+			}
+			return Guard(*command.Guards, handler)
+		}
+
+		switch command.Type {
+		case structs.UserCommand, structs.MessageCommand:
+			handler := command.Handler
+			if command.UserHandler != nil {
+				handler = userCommandHandler(command.UserHandler)
+			} else if command.MessageHandler != nil {
+				handler = messageCommandHandler(command.MessageHandler)
+			}
+			if handler != nil {
+				r.handleContextMenu(command.Type, command.Name, guard(handler))
+			}
+			continue
+		}
+
+		if command.Handler != nil {
+			r.Handle(command.Name, guard(command.Handler))
+		}
+		for path, handler := range command.Subcommands {
+			r.Handle(command.Name+" "+path, guard(handler))
+		}
+		for key, provider := range command.Autocomplete {
+			fields := strings.Fields(key)
+			if len(fields) == 0 {
+				return fmt.Errorf("empty autocomplete option on command %q", command.Name)
+			}
+			path := strings.Join(append([]string{command.Name}, fields[:len(fields)-1]...), " ")
+			r.Autocomplete(path, fields[len(fields)-1], provider)
+		}
+	}
+	return nil
+}
+
+// Commands returns the commands declared with `Register`.
+func (r *CommandRouter) Commands() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	commands := make([]Command, len(r.commands))
+	copy(commands, r.commands)
+	return commands
+}
+
+// Sync pushes the commands declared on the router to Discord using the session's token and application.
+func (r *CommandRouter) Sync(s ClientSession, opts SyncOptions) (*SyncPlan, error) {
+	botData := s.GetBotData()
+	if botData == nil || botData.ApplicationDetails == nil {
+		return nil, errors.New("application details not available, sync after the session is ready")
+	}
+	if opts.Translations == nil {
+		opts.Translations = r.Translations()
+	}
+	return SyncCommands(*s.GetToken(), botData.ApplicationDetails.ID.ToString(), r.Commands(), opts)
+}
+
+// SyncCommands compares the declared commands with the ones registered on Discord and only pushes the differences.
+// Global commands are compared with the global commands of the application, and guild commands with the commands of
+// every guild they are declared in. Guilds without any declared command are left alone, and so are the global commands
+// when none is declared, so syncing only guild commands never deletes the global commands registered some other way.
+//
+// Example:
+//
+//	plan, err := session.SyncCommands(token, applicationID, router.Commands(), session.SyncOptions{DryRun: true})
+//	// create global command "ping"
+//	// update guild 1234 command "config"
+//	// delete global command "old"
+func SyncCommands(token, applicationID string, commands []Command, opts SyncOptions) (*SyncPlan, error) {
+	commands = append([]Command(nil), commands...)
+	for i := range commands {
+		command := &commands[i]
+		if command.Type == 0 {
+			command.Type = structs.ChatInputCommand
+		}
+		if opts.Translations != nil {
+			*command = localizeCommand(opts.Translations, *command)
+		}
+	}
+
+	plan := &SyncPlan{}
+	for _, scope := range commandScopes(commands) {
+		remote, err := fetchCommands(token, applicationID, scope.guildID)
+		if err != nil {
+			return nil, err
+		}
+
+		changes, err := diffCommands(scope.commands, remote, scope.guildID, opts.KeepUnknown)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	if opts.DryRun {
+		out := opts.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		fmt.Fprintln(out, plan.String())
+		return plan, nil
+	}
+
+	for _, change := range plan.Changes {
+		if err := applyChange(token, applicationID, change); err != nil {
+			return plan, fmt.Errorf("failed to %s: %w", change.String(), err)
+		}
+	}
+	return plan, nil
+}
+
+// commandScope holds the commands declared in a guild, or the global commands when guildID is nil.
+type commandScope struct {
+	guildID  *structs.Snowflake
+	commands []*Command
+}
+
+// commandScopes groups the commands by where they are registered, global commands first and then the guilds by ID,
+// so the plan is the same on every run. There is no global scope unless a global command is declared.
+func commandScopes(commands []Command) []commandScope {
+	var global []*Command
+	guilds := map[uint64]*commandScope{}
+	for i := range commands {
+		command := &commands[i]
+		if len(command.GuildIDs) == 0 {
+			global = append(global, command)
+			continue
+		}
+		for _, guildID := range command.GuildIDs {
+			scope, ok := guilds[guildID.ID]
+			if !ok {
+				id := guildID
+				scope = &commandScope{guildID: &id}
+				guilds[guildID.ID] = scope
+			}
+			scope.commands = append(scope.commands, command)
+		}
+	}
+
+	var scopes []commandScope
+	if len(global) > 0 {
+		scopes = append(scopes, commandScope{commands: global})
+	}
+	ids := make([]uint64, 0, len(guilds))
+	for id := range guilds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		scopes = append(scopes, *guilds[id])
+	}
+	return scopes
+}
+
+func fetchCommands(token, applicationID string, guildID *structs.Snowflake) ([]structs.ApplicationCommand, error) {
+	reqDto := dto.GetGlobalApplicationCommandsDto{
+		ApplicationID:     applicationID,
+		WithLocalizations: util.ToPtr(true),
+	}
+	if guildID == nil {
+		return requestutil.GetGlobalApplicationCommands(reqDto, token)
+	}
+	return requestutil.GetGuildApplicationCommands(reqDto, applicationID, guildID.ToString(), token)
+}
+
+func diffCommands(declared []*Command, remote []structs.ApplicationCommand, guildID *structs.Snowflake, keepUnknown bool) ([]SyncChange, error) {
+	var changes []SyncChange
+	matched := make(map[string]bool)
+
+	for _, command := range declared {
+		key := commandKey(command.Name, command.Type)
+		if matched[key] {
+			return nil, fmt.Errorf("command %q is declared more than once", command.Name)
+		}
+		matched[key] = true
+
+		change := SyncChange{
+			Action:  SyncCreate,
+			Name:    command.Name,
+			Type:    command.Type,
+			GuildID: guildID,
+			command: command,
+		}
+
+		for _, existing := range remote {
+			if commandKey(existing.Name, existing.Type) != key {
+				continue
+			}
+
+			equal, err := commandsEqual(command, existing)
+			if err != nil {
+				return nil, err
+			}
+			if equal {
+				change.Action = ""
+			} else {
+				change.Action = SyncUpdate
+				change.CommandID = util.ToPtr(existing.ID)
+			}
+			break
+		}
+
+		if change.Action != "" {
+			changes = append(changes, change)
+		}
+	}
+
+	if keepUnknown {
+		return changes, nil
+	}
+
+	for _, existing := range remote {
+		if matched[commandKey(existing.Name, existing.Type)] {
+			continue
+		}
+		changes = append(changes, SyncChange{
+			Action:    SyncDelete,
+			Name:      existing.Name,
+			Type:      existing.Type,
+			GuildID:   guildID,
+			CommandID: util.ToPtr(existing.ID),
+		})
+	}
+	return changes, nil
+}
+
+func commandKey(name string, commandType structs.ApplicationCommandType) string {
+	if commandType == 0 {
+		commandType = structs.ChatInputCommand
+	}
+	return strconv.Itoa(int(commandType)) + ":" + name
+}
+
+// commandsEqual compares the declared command with the one on Discord by their JSON representation.
+func commandsEqual(command *Command, existing structs.ApplicationCommand) (bool, error) {
+	local := command.payload()
+	remote := commandPayload{
+		Name:                     existing.Name,
+		NameLocalizations:        existing.NameLocalizations,
+		Description:              existing.Description,
+		DescriptionLocalizations: existing.DescriptionLocalizations,
+		DefaultMemberPermissions: existing.DefaultMemberPermissions,
+		Contexts:                 existing.Contexts,
+		IntegrationTypes:         existing.IntegrationTypes,
+		NSFW:                     existing.NSFW,
+		Type:                     existing.Type,
+	}
+	if existing.Options != nil {
+		remote.Options = *existing.Options
+	}
+
+	// Discord fills in defaults for these, only compare them when they were declared
+	if local.Contexts == nil {
+		remote.Contexts = nil
+	}
+	if local.IntegrationTypes == nil {
+		remote.IntegrationTypes = nil
+	}
+
+	localJSON, err := json.Marshal(local)
+	if err != nil {
+		return false, err
+	}
+	remoteJSON, err := json.Marshal(remote)
+	if err != nil {
+		return false, err
+	}
+	return string(localJSON) == string(remoteJSON), nil
+}
+
+func (c *Command) payload() commandPayload {
+	return commandPayload{
+		Name:                     c.Name,
+		NameLocalizations:        c.NameLocalizations,
+		Description:              c.Description,
+		DescriptionLocalizations: c.DescriptionLocalizations,
+		Options:                  c.Options,
+		DefaultMemberPermissions: c.DefaultMemberPermissions,
+		Contexts:                 c.Contexts,
+		IntegrationTypes:         c.IntegrationTypes,
+		NSFW:                     c.NSFW,
+		Type:                     c.Type,
+	}
+}
+
+// applyChange pushes a single change to Discord, creating a command with the name of an existing one overwrites it.
+func applyChange(token, applicationID string, change SyncChange) error {
+	if change.Action == SyncDelete {
+		if change.GuildID == nil {
+			return requestutil.DeleteGlobalApplicationCommand(applicationID, change.CommandID.ToString(), token)
+		}
+		return requestutil.DeleteGuildApplicationCommand(applicationID, change.GuildID.ToString(), change.CommandID.ToString(), token)
+	}
+
+	command := change.command
+	if change.GuildID == nil {
+		reqDto := dto.NewGlobalApplicationCommandDto(command.Name, &command.Type)
+		if err := fillCommandDto(reqDto, command); err != nil {
+			return err
+		}
+		if command.Contexts != nil {
+			reqDto.SetContexts(command.Contexts)
+		}
+		if command.IntegrationTypes != nil {
+			reqDto.SetIntegrationTypes(command.IntegrationTypes)
+		}
+		_, err := requestutil.CreateGlobalApplicationCommand(reqDto, applicationID, token)
+		return err
+	}
+
+	reqDto := dto.NewGuildApplicationCommandDto(command.Name, &command.Type)
+	if err := fillCommandDto(reqDto, command); err != nil {
+		return err
+	}
+	_, err := requestutil.CreateGuildApplicationCommand(reqDto, applicationID, change.GuildID.ToString(), token)
+	return err
+}
+
+// commandDto is the part shared by the global and guild command DTOs.
+type commandDto interface {
+	SetNameLocalizations(localizations map[string]string) error
+	SetDescription(description string) error
+	SetDescriptionLocalizations(localizations map[string]string) error
+	SetOptions(options []structs.ApplicationCommandOption) error
+	SetDefaultMemberPermissions(permissions structs.Bitfield[structs.Permission])
+	SetNsfw(nsfw bool)
+}
+
+func fillCommandDto(reqDto commandDto, command *Command) error {
+	if command.NameLocalizations != nil {
+		if err := reqDto.SetNameLocalizations(command.NameLocalizations); err != nil {
+			return err
+		}
+	}
+	if command.Description != "" {
+		if err := reqDto.SetDescription(command.Description); err != nil {
+			return err
+		}
+	}
+	if command.DescriptionLocalizations != nil {
+		if err := reqDto.SetDescriptionLocalizations(command.DescriptionLocalizations); err != nil {
+			return err
+		}
+	}
+	if command.Options != nil {
+		if err := reqDto.SetOptions(command.Options); err != nil {
+			return err
+		}
+	}
+	if command.DefaultMemberPermissions != nil {
+		reqDto.SetDefaultMemberPermissions(*command.DefaultMemberPermissions)
+	}
+	reqDto.SetNsfw(command.NSFW)
+	return nil
+}
@@ -0,0 +1,300 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/util"
+)
+
+func syncTestCommand() Command {
+	return Command{
+		Name:        "config",
+		Description: "Configure the bot",
+		Type:        structs.ChatInputCommand,
+		Options: []structs.ApplicationCommandOption{{
+			Type:        structs.IntegerOptionType,
+			Name:        "level",
+			Description: "The level",
+			Required:    true,
+			MinValue:    float64Ptr(1),
+			Choices: []structs.ApplicationCommandOptionChoice{
+				{Name: "low", Value: 1},
+				{Name: "high", Value: 2},
+			},
+		}},
+	}
+}
+
+// remoteCommand returns the command the way Discord sends it back, with the defaults it fills in.
+func remoteCommand(command Command, id uint64) structs.ApplicationCommand {
+	options := make([]structs.ApplicationCommandOption, len(command.Options))
+	for i, option := range command.Options {
+		option.NameLocalizations = map[string]string{}
+		option.DescriptionLocalizations = map[string]string{}
+		choices := make([]structs.ApplicationCommandOptionChoice, len(option.Choices))
+		for j, choice := range option.Choices {
+			// numbers come back from JSON as float64
+			if n, ok := choice.Value.(int); ok {
+				choice.Value = float64(n)
+			}
+			choices[j] = choice
+		}
+		option.Choices = choices
+		options[i] = option
+	}
+
+	return structs.ApplicationCommand{
+		ID:                       *structs.NewSnowflake(id),
+		Type:                     command.Type,
+		Name:                     command.Name,
+		NameLocalizations:        map[string]string{},
+		Description:              command.Description,
+		DescriptionLocalizations: map[string]string{},
+		Options:                  &options,
+		DmPermission:             boolPtr(true),
+		IntegrationTypes:         []structs.IntegrationType{0},
+		Contexts:                 []structs.IntegrationContextType{0, 1, 2},
+		Version:                  *structs.NewSnowflake(id + 1),
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestCommandScopes(t *testing.T) {
+	global := Command{Name: "ping"}
+	guildA := Command{Name: "a", GuildIDs: []structs.Snowflake{*structs.NewSnowflake(200), *structs.NewSnowflake(30)}}
+	guildB := Command{Name: "b", GuildIDs: []structs.Snowflake{*structs.NewSnowflake(30)}}
+
+	tests := []struct {
+		name     string
+		commands []Command
+		// want lists the guild ID of every scope in order, 0 for the global scope, with the names of its commands
+		want [][]any
+	}{
+		{name: "no commands"},
+		{
+			name:     "only guild commands have no global scope",
+			commands: []Command{guildA, guildB},
+			want:     [][]any{{uint64(30), "a", "b"}, {uint64(200), "a"}},
+		},
+		{
+			name:     "global first, then guilds by ID",
+			commands: []Command{guildB, global, guildA},
+			want:     [][]any{{uint64(0), "ping"}, {uint64(30), "b", "a"}, {uint64(200), "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]any
+			for _, scope := range commandScopes(tt.commands) {
+				id := uint64(0)
+				if scope.guildID != nil {
+					id = scope.guildID.ID
+				}
+				entry := []any{id}
+				for _, command := range scope.commands {
+					entry = append(entry, command.Name)
+				}
+				got = append(got, entry)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("commandScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandsEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(local *Command, remote *structs.ApplicationCommand)
+		want   bool
+	}{
+		{name: "defaults filled in by Discord", modify: func(*Command, *structs.ApplicationCommand) {}, want: true},
+		{
+			name: "declared contexts are compared",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.Contexts = []structs.IntegrationContextType{0}
+			},
+			want: false,
+		},
+		{
+			name: "declared contexts matching",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.Contexts = []structs.IntegrationContextType{0, 1, 2}
+			},
+			want: true,
+		},
+		{
+			name: "description changed",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.Description = "Configure the bot for the server"
+			},
+			want: false,
+		},
+		{
+			name: "option made optional",
+			modify: func(_ *Command, remote *structs.ApplicationCommand) {
+				(*remote.Options)[0].Required = false
+			},
+			want: false,
+		},
+		{
+			name: "choice added",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.Options[0].Choices = append(local.Options[0].Choices, structs.ApplicationCommandOptionChoice{Name: "max", Value: 3})
+			},
+			want: false,
+		},
+		{
+			name: "default member permissions declared",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.DefaultMemberPermissions = util.ToPtr(structs.NewBitfield(structs.ManageGuild))
+			},
+			want: false,
+		},
+		{
+			name: "default member permissions matching",
+			modify: func(local *Command, remote *structs.ApplicationCommand) {
+				local.DefaultMemberPermissions = util.ToPtr(structs.NewBitfield(structs.ManageGuild))
+				remote.DefaultMemberPermissions = util.ToPtr(structs.NewBitfield(structs.ManageGuild))
+			},
+			want: true,
+		},
+		{
+			name: "localization added",
+			modify: func(local *Command, _ *structs.ApplicationCommand) {
+				local.DescriptionLocalizations = map[string]string{"de": "Den Bot konfigurieren"}
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := syncTestCommand()
+			remote := remoteCommand(syncTestCommand(), 10)
+			tt.modify(&local, &remote)
+
+			equal, err := commandsEqual(&local, remote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if equal != tt.want {
+				t.Fatalf("commandsEqual() = %v, want %v", equal, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffCommands(t *testing.T) {
+	config := syncTestCommand()
+	ping := Command{Name: "ping", Description: "Pong", Type: structs.ChatInputCommand}
+	user := Command{Name: "Info", Type: structs.UserCommand}
+	changed := syncTestCommand()
+	changed.Description = "Changed"
+	guildID := structs.NewSnowflake(30)
+
+	type change struct {
+		action    SyncAction
+		name      string
+		commandID uint64
+	}
+
+	tests := []struct {
+		name        string
+		declared    []Command
+		remote      []structs.ApplicationCommand
+		keepUnknown bool
+		want        []change
+	}{
+		{
+			name:     "remote already matches",
+			declared: []Command{config, ping},
+			remote:   []structs.ApplicationCommand{remoteCommand(ping, 20), remoteCommand(config, 10)},
+		},
+		{
+			name:     "create missing commands",
+			declared: []Command{config, ping},
+			remote:   []structs.ApplicationCommand{remoteCommand(config, 10)},
+			want:     []change{{action: SyncCreate, name: "ping"}},
+		},
+		{
+			name:     "update changed commands",
+			declared: []Command{changed},
+			remote:   []structs.ApplicationCommand{remoteCommand(config, 10)},
+			want:     []change{{action: SyncUpdate, name: "config", commandID: 10}},
+		},
+		{
+			name:     "delete unknown commands",
+			declared: []Command{config},
+			remote:   []structs.ApplicationCommand{remoteCommand(config, 10), remoteCommand(ping, 20)},
+			want:     []change{{action: SyncDelete, name: "ping", commandID: 20}},
+		},
+		{
+			name:        "keep unknown commands",
+			declared:    []Command{config},
+			remote:      []structs.ApplicationCommand{remoteCommand(config, 10), remoteCommand(ping, 20)},
+			keepUnknown: true,
+		},
+		{
+			name:     "same name with another type is another command",
+			declared: []Command{{Name: "ping", Type: structs.UserCommand}},
+			remote:   []structs.ApplicationCommand{remoteCommand(ping, 20)},
+			want: []change{
+				{action: SyncCreate, name: "ping"},
+				{action: SyncDelete, name: "ping", commandID: 20},
+			},
+		},
+		{
+			name:     "context-menu command matches",
+			declared: []Command{user},
+			remote:   []structs.ApplicationCommand{remoteCommand(user, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			declared := make([]*Command, len(tt.declared))
+			for i := range tt.declared {
+				declared[i] = &tt.declared[i]
+			}
+
+			changes, err := diffCommands(declared, tt.remote, guildID, tt.keepUnknown)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []change
+			for _, c := range changes {
+				if c.GuildID != guildID {
+					t.Fatalf("change %s has guild %v, want %v", c, c.GuildID, guildID)
+				}
+				id := uint64(0)
+				if c.CommandID != nil {
+					id = c.CommandID.ID
+				}
+				got = append(got, change{action: c.Action, name: c.Name, commandID: id})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffCommands() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffCommandsRejectsDuplicates(t *testing.T) {
+	ping := Command{Name: "ping", Type: structs.ChatInputCommand}
+	if _, err := diffCommands([]*Command{&ping, &ping}, nil, nil, false); err == nil {
+		t.Fatal("diffCommands() accepted a command declared twice")
+	}
+}
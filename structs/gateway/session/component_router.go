@@ -0,0 +1,138 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// customIDPattern matches custom IDs against a pattern like `vote:{pollID}:{choice}`, capturing the named parts.
+type customIDPattern struct {
+	pattern string
+	re      *regexp.Regexp
+	params  []string
+	handler CommandHandler
+}
+
+var patternParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+func newCustomIDPattern(pattern string, handler CommandHandler) (*customIDPattern, error) {
+	p := &customIDPattern{pattern: pattern, handler: handler}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range patternParam.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		expr.WriteString("(.+?)")
+		p.params = append(p.params, pattern[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid custom ID pattern %q: %w", pattern, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+func (p *customIDPattern) match(customID string) (map[string]string, bool) {
+	matches := p.re.FindStringSubmatch(customID)
+	if matches == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(p.params))
+	for i, name := range p.params {
+		params[name] = matches[i+1]
+	}
+	return params, true
+}
+
+// Component registers a handler for message components whose custom ID matches the pattern.
+// Parts of the pattern in braces match any text and are available through `ctx.Param`.
+// Patterns are tried in the order they were registered.
+//
+// Example:
+//
+//	router.Component("vote:{pollID}:{choice}", func(ctx *session.CommandContext) error {
+//	    polls.Vote(ctx.Param("pollID"), ctx.Param("choice"), ctx.Author().ID)
+//	    return ctx.DeferUpdate()
+//	})
+func (r *CommandRouter) Component(pattern string, handler CommandHandler) error {
+	p, err := newCustomIDPattern(pattern, handler)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components = append(r.components, p)
+	return nil
+}
+
+// LookupComponent returns the handler of the first pattern matching the custom ID, along with the captured parts.
+func (r *CommandRouter) LookupComponent(customID string) (CommandHandler, map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return lookupPattern(r.components, customID)
+}
+
+func lookupPattern(patterns []*customIDPattern, customID string) (CommandHandler, map[string]string, bool) {
+	for _, p := range patterns {
+		if params, ok := p.match(customID); ok && p.handler != nil {
+			return p.handler, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Param returns a part of the custom ID captured by the component pattern, i.e `pollID` for `vote:{pollID}:{choice}`.
+func (c *CommandContext) Param(name string) string {
+	return c.Params[name]
+}
+
+// CustomID returns the custom ID of the component or modal that was used.
+func (c *CommandContext) CustomID() string {
+	if c.Interaction == nil || c.Interaction.Data == nil {
+		return ""
+	}
+	return c.Interaction.Data.CustomID
+}
+
+// Values returns the values picked in a select menu.
+func (c *CommandContext) Values() []string {
+	if c.Interaction == nil || c.Interaction.Data == nil {
+		return nil
+	}
+	return c.Interaction.Data.Values
+}
+
+// Update edits the message the component is attached to, instead of sending a new one.
+func (c *CommandContext) Update(response structs.InteractionResponseOptions) error {
+	response.SetResponseType(structs.UpdateMessageInteraction)
+	if err := structs.ValidateComponents(response.InteractionResponse().Data.Components); err != nil {
+		return err
+	}
+	return c.respond(response)
+}
+
+// UpdateContent edits the content and components of the message the component is attached to.
+func (c *CommandContext) UpdateContent(content string, components []structs.MessageComponent) error {
+	response := structs.NewInteractionResponseOptions()
+	response.SetContent(content)
+	response.SetComponents(components)
+	return c.Update(response)
+}
+
+// DeferUpdate acknowledges the component without changing the message, the message can still be edited later.
+func (c *CommandContext) DeferUpdate() error {
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.DeferredUpdatedMessageInteraction)
+	return c.respond(response)
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func TestCustomIDPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		customID string
+		want     map[string]string
+		wantOK   bool
+	}{
+		{name: "literal", pattern: "close", customID: "close", want: map[string]string{}, wantOK: true},
+		{name: "literal with a suffix", pattern: "close", customID: "close:1", wantOK: false},
+		{
+			name:     "params",
+			pattern:  "vote:{pollID}:{choice}",
+			customID: "vote:123:yes",
+			want:     map[string]string{"pollID": "123", "choice": "yes"},
+			wantOK:   true,
+		},
+		{
+			name:     "last param keeps the separators",
+			pattern:  "vote:{pollID}:{choice}",
+			customID: "vote:123:yes:no",
+			want:     map[string]string{"pollID": "123", "choice": "yes:no"},
+			wantOK:   true,
+		},
+		{name: "empty param", pattern: "vote:{pollID}:{choice}", customID: "vote::yes", wantOK: false},
+		{name: "other prefix", pattern: "vote:{pollID}", customID: "poll:123", wantOK: false},
+		{
+			name:     "regexp characters are literal",
+			pattern:  "page.{n}+",
+			customID: "page.2+",
+			want:     map[string]string{"n": "2"},
+			wantOK:   true,
+		},
+		{name: "regexp characters don't match anything else", pattern: "page.{n}+", customID: "pageX2+", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newCustomIDPattern(tt.pattern, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := p.match(tt.customID)
+			if ok != tt.wantOK {
+				t.Fatalf("match(%q) ok = %v, want %v", tt.customID, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("match(%q) = %v, want %v", tt.customID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteComponent(t *testing.T) {
+	var called string
+	router := NewCommandRouter()
+	for _, pattern := range []string{"vote:{pollID}:{choice}", "vote:{pollID}", "close"} {
+		pattern := pattern
+		if err := router.Component(pattern, func(*CommandContext) error {
+			called = pattern
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		customID    string
+		wantPattern string
+		wantParams  map[string]string
+	}{
+		// patterns are tried in the order they were registered
+		{customID: "vote:1:yes", wantPattern: "vote:{pollID}:{choice}", wantParams: map[string]string{"pollID": "1", "choice": "yes"}},
+		{customID: "vote:1", wantPattern: "vote:{pollID}", wantParams: map[string]string{"pollID": "1"}},
+		{customID: "close", wantPattern: "close", wantParams: map[string]string{}},
+		{customID: "open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.customID, func(t *testing.T) {
+			called = ""
+			ctx := commandContext(t, map[string]any{
+				"type": structs.MessageComponentInteraction,
+				"data": map[string]any{"custom_id": tt.customID, "component_type": 2},
+			})
+
+			handler, ok := router.route(ctx)
+			if ok != (tt.wantPattern != "") {
+				t.Fatalf("route() ok = %v, want %v", ok, tt.wantPattern != "")
+			}
+			if !ok {
+				return
+			}
+			if err := handler(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if called != tt.wantPattern {
+				t.Fatalf("routed to %q, want %q", called, tt.wantPattern)
+			}
+			for name, want := range tt.wantParams {
+				if got := ctx.Param(name); got != want {
+					t.Fatalf("Param(%q) = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
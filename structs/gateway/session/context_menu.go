@@ -0,0 +1,112 @@
+package session
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// UserCommandHandler handles a user context-menu command, receiving the user the command was used on.
+// The member is nil when the command was used outside of a guild.
+type UserCommandHandler func(ctx *CommandContext, user structs.User, member *structs.GuildMember) error
+
+// MessageCommandHandler handles a message context-menu command, receiving the message the command was used on.
+type MessageCommandHandler func(ctx *CommandContext, message structs.Message) error
+
+// UserCommand registers a handler for the user context-menu command with the given name.
+// Context-menu commands are routed separately from slash commands, so both can share a name.
+//
+// Example:
+//
+//	router.UserCommand("Show Avatar", func(ctx *session.CommandContext, user structs.User, member *structs.GuildMember) error {
+//	    return ctx.ReplyEphemeral(user.Username + "'s avatar")
+//	})
+func (r *CommandRouter) UserCommand(name string, handler UserCommandHandler) {
+	r.handleContextMenu(structs.UserCommand, name, userCommandHandler(handler))
+}
+
+// MessageCommand registers a handler for the message context-menu command with the given name.
+// Context-menu commands are routed separately from slash commands, so both can share a name.
+//
+// Example:
+//
+//	router.MessageCommand("Bookmark", func(ctx *session.CommandContext, message structs.Message) error {
+//	    return ctx.ReplyEphemeral("saved " + message.ID.ToString())
+//	})
+func (r *CommandRouter) MessageCommand(name string, handler MessageCommandHandler) {
+	r.handleContextMenu(structs.MessageCommand, name, messageCommandHandler(handler))
+}
+
+// LookupContextMenu returns the handler registered for the user or message command with the given name.
+func (r *CommandRouter) LookupContextMenu(commandType structs.ApplicationCommandType, name string) (CommandHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.contextMenus[contextMenuKey(commandType, name)]
+	return handler, ok && handler != nil
+}
+
+func (r *CommandRouter) handleContextMenu(commandType structs.ApplicationCommandType, name string, handler CommandHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contextMenus[contextMenuKey(commandType, name)] = handler
+}
+
+func userCommandHandler(handler UserCommandHandler) CommandHandler {
+	return func(ctx *CommandContext) error {
+		user, member := ctx.TargetUser()
+		if user == nil {
+			return errors.New("user command has no resolved target user")
+		}
+		return handler(ctx, *user, member)
+	}
+}
+
+func messageCommandHandler(handler MessageCommandHandler) CommandHandler {
+	return func(ctx *CommandContext) error {
+		message := ctx.TargetMessage()
+		if message == nil {
+			return errors.New("message command has no resolved target message")
+		}
+		return handler(ctx, *message)
+	}
+}
+
+// TargetID returns the ID of the user or message a context-menu command was used on.
+func (c *CommandContext) TargetID() *structs.Snowflake {
+	if c.Interaction == nil || c.Interaction.Data == nil {
+		return nil
+	}
+	return c.Interaction.Data.TargetID
+}
+
+// TargetUser returns the user a user command was used on, along with their member when used in a guild.
+func (c *CommandContext) TargetUser() (*structs.User, *structs.GuildMember) {
+	id := c.TargetID()
+	if id == nil {
+		return nil, nil
+	}
+	resolved := c.Interaction.Data.Resolved
+	return resolved.GetUser(*id), resolved.GetMember(*id)
+}
+
+// TargetMessage returns the message a message command was used on.
+func (c *CommandContext) TargetMessage() *structs.Message {
+	id := c.TargetID()
+	if id == nil {
+		return nil
+	}
+	return c.Interaction.Data.Resolved.GetMessage(*id)
+}
+
+// isContextMenu reports whether the interaction data belongs to a user or message command.
+func isContextMenu(data *structs.InteractionData) bool {
+	commandType := structs.ApplicationCommandType(data.Type)
+	return commandType == structs.UserCommand || commandType == structs.MessageCommand
+}
+
+// context-menu names can contain spaces and mixed case, so unlike command paths they are kept as is
+func contextMenuKey(commandType structs.ApplicationCommandType, name string) string {
+	return strconv.Itoa(int(commandType)) + ":" + strings.TrimSpace(name)
+}
@@ -0,0 +1,343 @@
+package session
+
+import (
+	"hash/fnv"
+	"log"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+)
+
+// OverflowPolicy decides what the dispatcher does with an event when the queue it belongs to is full.
+type OverflowPolicy int
+
+const (
+	// OverflowSpill moves the event to an unbounded overflow buffer that is drained back into the queue in order.
+	// It is the default, since it never holds up the gateway reader.
+	OverflowSpill OverflowPolicy = iota
+	// OverflowBlock blocks the gateway reader until there is room in the queue, applying backpressure to the connection.
+	// Heartbeat ACKs aren't read while it waits, so a pool busy for longer than the heartbeat interval gets the
+	// connection resumed as if it were a zombie.
+	OverflowBlock
+	// OverflowDrop discards the event and counts it in `DispatcherStats.Dropped`.
+	OverflowDrop
+)
+
+// DispatchOrdering decides which events are guaranteed to be handled in the order they were received.
+type DispatchOrdering int
+
+const (
+	// NoOrdering lets any worker pick up any event.
+	NoOrdering DispatchOrdering = iota
+	// GuildOrdering handles events from the same guild one at a time, in order.
+	GuildOrdering
+	// ChannelOrdering handles events from the same channel one at a time, in order. Events without a channel fall back to their guild.
+	ChannelOrdering
+)
+
+// DispatcherOptions configures the bounded worker pool used to handle gateway dispatch events.
+type DispatcherOptions struct {
+	// Workers is the amount of goroutines handling events, defaults to the number of CPUs.
+	Workers int
+	// QueueSize is the capacity of each queue, defaults to 256.
+	QueueSize int
+	// Ordering is the key used to keep related events in order.
+	Ordering DispatchOrdering
+	// Overflow is the policy applied when a queue is full, defaults to OverflowSpill.
+	Overflow OverflowPolicy
+}
+
+// DispatcherStats is a snapshot of the dispatcher's queues.
+type DispatcherStats struct {
+	// QueueDepth is the amount of events waiting to be handled, including spilled events.
+	QueueDepth int64
+	// Spilled is the amount of events currently waiting in the overflow buffers.
+	Spilled int64
+	// Dropped is the total amount of events discarded by the OverflowDrop policy.
+	Dropped uint64
+	// Processed is the total amount of events handled.
+	Processed uint64
+}
+
+type dispatchQueue struct {
+	tasks chan func()
+
+	spillMu     *sync.Mutex
+	spill       []func()
+	spillSignal chan struct{}
+}
+
+type dispatcher struct {
+	opts   DispatcherOptions
+	queues []*dispatchQueue
+	next   atomic.Uint64
+
+	// stopMu keeps submit from queueing into a dispatcher being stopped, submit holds the read lock
+	stopMu  *sync.RWMutex
+	stopped bool
+	spillWG *sync.WaitGroup
+
+	depth     atomic.Int64
+	spilled   atomic.Int64
+	dropped   atomic.Uint64
+	processed atomic.Uint64
+}
+
+func newDispatcher(opts DispatcherOptions) *dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+
+	d := &dispatcher{
+		opts:    opts,
+		stopMu:  &sync.RWMutex{},
+		spillWG: &sync.WaitGroup{},
+	}
+
+	// unordered dispatch shares a single queue between every worker, ordered dispatch gives each worker its own queue
+	queueCount := 1
+	if opts.Ordering != NoOrdering {
+		queueCount = opts.Workers
+	}
+
+	for i := 0; i < queueCount; i++ {
+		q := &dispatchQueue{
+			tasks:       make(chan func(), opts.QueueSize),
+			spillMu:     &sync.Mutex{},
+			spillSignal: make(chan struct{}, 1),
+		}
+		d.queues = append(d.queues, q)
+		d.spillWG.Add(1)
+		go d.drainSpill(q)
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		go d.work(d.queues[i%queueCount])
+	}
+
+	return d
+}
+
+// submit queues the task according to the ordering and overflow policy of the dispatcher,
+// and reports false without queueing it if the dispatcher was stopped.
+func (d *dispatcher) submit(p payload.SessionPayload, task func()) bool {
+	d.stopMu.RLock()
+	defer d.stopMu.RUnlock()
+	if d.stopped {
+		return false
+	}
+
+	q := d.queueFor(p)
+	d.depth.Add(1)
+
+	switch d.opts.Overflow {
+	case OverflowDrop:
+		select {
+		case q.tasks <- task:
+		default:
+			d.depth.Add(-1)
+			d.dropped.Add(1)
+			log.Printf("dispatcher queue full, dropping %s event", eventName(p))
+		}
+		return true
+	case OverflowBlock:
+		q.tasks <- task
+		return true
+	default:
+		q.spillMu.Lock()
+		defer q.spillMu.Unlock()
+
+		// once something spilled, everything after it has to spill too so the order is kept
+		if len(q.spill) == 0 {
+			select {
+			case q.tasks <- task:
+				return true
+			default:
+			}
+		}
+
+		q.spill = append(q.spill, task)
+		d.spilled.Add(1)
+		select {
+		case q.spillSignal <- struct{}{}:
+		default:
+		}
+		return true
+	}
+}
+
+// stop stops taking new tasks, the workers exit once every task already queued, spilled ones included, is handled.
+func (d *dispatcher) stop() {
+	d.stopMu.Lock()
+	if d.stopped {
+		d.stopMu.Unlock()
+		return
+	}
+	d.stopped = true
+	d.stopMu.Unlock()
+
+	// the spilled tasks go back into the queues before they are closed
+	for _, q := range d.queues {
+		close(q.spillSignal)
+	}
+	d.spillWG.Wait()
+	for _, q := range d.queues {
+		close(q.tasks)
+	}
+}
+
+func (d *dispatcher) work(q *dispatchQueue) {
+	for task := range q.tasks {
+		d.depth.Add(-1)
+		task()
+		d.processed.Add(1)
+	}
+}
+
+// drainSpill moves spilled tasks back into the queue in the order they were spilled.
+func (d *dispatcher) drainSpill(q *dispatchQueue) {
+	defer d.spillWG.Done()
+	for range q.spillSignal {
+		for {
+			q.spillMu.Lock()
+			if len(q.spill) == 0 {
+				q.spillMu.Unlock()
+				break
+			}
+			task := q.spill[0]
+			q.spillMu.Unlock()
+
+			q.tasks <- task
+
+			q.spillMu.Lock()
+			q.spill[0] = nil
+			q.spill = q.spill[1:]
+			q.spillMu.Unlock()
+			d.spilled.Add(-1)
+		}
+	}
+}
+
+func (d *dispatcher) queueFor(p payload.SessionPayload) *dispatchQueue {
+	if len(d.queues) == 1 {
+		return d.queues[0]
+	}
+
+	key := dispatchKey(p, d.opts.Ordering)
+	if key == "" {
+		return d.queues[d.next.Add(1)%uint64(len(d.queues))]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return d.queues[h.Sum32()%uint32(len(d.queues))]
+}
+
+func (d *dispatcher) stats() DispatcherStats {
+	return DispatcherStats{
+		QueueDepth: d.depth.Load(),
+		Spilled:    d.spilled.Load(),
+		Dropped:    d.dropped.Load(),
+		Processed:  d.processed.Load(),
+	}
+}
+
+// dispatchKey finds the guild or channel an event belongs to, events without either return an empty key.
+func dispatchKey(p payload.SessionPayload, ordering DispatchOrdering) string {
+	if p.Data == nil || p.EventName == nil {
+		return ""
+	}
+	name := *p.EventName
+
+	if ordering == ChannelOrdering {
+		if id := snowflakeField(p.Data, "ChannelID"); id != nil {
+			return id.ToString()
+		}
+		if strings.HasPrefix(name, "CHANNEL_") || strings.HasPrefix(name, "THREAD_") {
+			if id := snowflakeField(p.Data, "ID"); id != nil {
+				return id.ToString()
+			}
+		}
+	}
+
+	if id := eventGuildID(p); id != nil {
+		return id.ToString()
+	}
+	return ""
+}
+
+// snowflakeField reads a Snowflake or *Snowflake field from an event struct, including promoted fields from embedded structs.
+func snowflakeField(data any, name string) *structs.Snowflake {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field, ok := v.Type().FieldByName(name)
+	if !ok {
+		return nil
+	}
+	fv, err := v.FieldByIndexErr(field.Index)
+	if err != nil {
+		return nil
+	}
+
+	var id *structs.Snowflake
+	switch v := fv.Interface().(type) {
+	case structs.Snowflake:
+		id = &v
+	case *structs.Snowflake:
+		id = v
+	}
+
+	// a zero snowflake means the field was omitted from the payload
+	if id == nil || id.ID == 0 {
+		return nil
+	}
+	return id
+}
+
+// SetDispatcher routes dispatch events through a bounded worker pool configured by opts.
+// Opcode events such as heartbeats and reconnects are never queued, so a busy pool can't stall the connection.
+// The dispatcher lives as long as the event handler, which is kept across reconnects and resumes.
+// Setting a new dispatcher stops the previous one, its workers exit once the events already queued are handled.
+func (e *eventHandler) SetDispatcher(opts DispatcherOptions) {
+	e.dispMu.Lock()
+	previous := e.dispatcher
+	e.dispatcher = newDispatcher(opts)
+	e.dispMu.Unlock()
+
+	if previous != nil {
+		// stopping waits for the queues to drain, which must not hold up the caller
+		go previous.stop()
+	}
+}
+
+// DispatcherStats returns a snapshot of the dispatcher queues, and false when no dispatcher is configured.
+func (e *eventHandler) DispatcherStats() (DispatcherStats, bool) {
+	d := e.getDispatcher()
+	if d == nil {
+		return DispatcherStats{}, false
+	}
+	return d.stats(), true
+}
+
+func (e *eventHandler) getDispatcher() *dispatcher {
+	e.dispMu.RLock()
+	defer e.dispMu.RUnlock()
+	return e.dispatcher
+}
@@ -0,0 +1,181 @@
+package session
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util"
+)
+
+func messagePayload(guildID, channelID uint64) payload.SessionPayload {
+	event := receiveevents.MessageCreateEvent{Message: &structs.Message{ChannelID: *structs.NewSnowflake(channelID)}}
+	if guildID != 0 {
+		event.GuildID = structs.NewSnowflake(guildID)
+	}
+	return payload.SessionPayload{EventName: util.ToPtr("MESSAGE_CREATE"), Data: event}
+}
+
+func TestDispatchKey(t *testing.T) {
+	channelUpdate := payload.SessionPayload{
+		EventName: util.ToPtr("CHANNEL_UPDATE"),
+		Data:      receiveevents.ChannelUpdateEvent{Channel: &structs.Channel{ID: *structs.NewSnowflake(7), GuildID: structs.NewSnowflake(1)}},
+	}
+	guildUpdate := payload.SessionPayload{
+		EventName: util.ToPtr("GUILD_UPDATE"),
+		Data:      receiveevents.GuildUpdateEvent{Guild: &structs.Guild{ID: *structs.NewSnowflake(1)}},
+	}
+
+	tests := []struct {
+		name     string
+		p        payload.SessionPayload
+		ordering DispatchOrdering
+		want     string
+	}{
+		{name: "guild ordering uses the guild", p: messagePayload(1, 2), ordering: GuildOrdering, want: "1"},
+		{name: "channel ordering uses the channel", p: messagePayload(1, 2), ordering: ChannelOrdering, want: "2"},
+		{name: "direct message without a guild", p: messagePayload(0, 2), ordering: GuildOrdering, want: ""},
+		{name: "channel event is its own channel", p: channelUpdate, ordering: ChannelOrdering, want: "7"},
+		{name: "channel event by guild", p: channelUpdate, ordering: GuildOrdering, want: "1"},
+		{name: "guild event is its own guild", p: guildUpdate, ordering: ChannelOrdering, want: "1"},
+		{name: "no data", p: payload.SessionPayload{EventName: util.ToPtr("RESUMED")}, ordering: GuildOrdering, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dispatchKey(tt.p, tt.ordering); got != tt.want {
+				t.Fatalf("dispatchKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherGuildOrdering(t *testing.T) {
+	d := newDispatcher(DispatcherOptions{Workers: 4, QueueSize: 8, Ordering: GuildOrdering})
+	defer d.stop()
+
+	const guilds, events = 3, 200
+	mu := &sync.Mutex{}
+	handled := map[uint64][]int{}
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < events; i++ {
+		for guild := uint64(1); guild <= guilds; guild++ {
+			guild, i := guild, i
+			wg.Add(1)
+			d.submit(messagePayload(guild, guild*10), func() {
+				defer wg.Done()
+				mu.Lock()
+				defer mu.Unlock()
+				handled[guild] = append(handled[guild], i)
+			})
+		}
+	}
+	wg.Wait()
+
+	for guild := uint64(1); guild <= guilds; guild++ {
+		for i, got := range handled[guild] {
+			if got != i {
+				t.Fatalf("guild %d handled event %d at position %d", guild, got, i)
+			}
+		}
+		if len(handled[guild]) != events {
+			t.Fatalf("guild %d handled %d events, want %d", guild, len(handled[guild]), events)
+		}
+	}
+}
+
+// blockDispatcher submits a task that holds the only worker of d until release is closed,
+// and waits for the worker to pick it up so the queue is empty.
+func blockDispatcher(t *testing.T, d *dispatcher, release chan struct{}) {
+	t.Helper()
+	started := make(chan struct{})
+	d.submit(messagePayload(1, 1), func() {
+		close(started)
+		<-release
+	})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker never picked up the blocking task")
+	}
+}
+
+func TestDispatcherSpill(t *testing.T) {
+	d := newDispatcher(DispatcherOptions{Workers: 1, QueueSize: 2})
+	defer d.stop()
+
+	release := make(chan struct{})
+	blockDispatcher(t, d, release)
+
+	mu := &sync.Mutex{}
+	var handled []int
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		// a full queue must not block the caller, which is the gateway reader
+		if !d.submit(messagePayload(1, 1), func() {
+			defer wg.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, i)
+		}) {
+			t.Fatal("submit() = false on a running dispatcher")
+		}
+	}
+
+	if stats := d.stats(); stats.Spilled == 0 || stats.QueueDepth != 10 {
+		t.Fatalf("stats = %+v, want spilled events and a depth of 10", stats)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(handled, want) {
+		t.Fatalf("handled = %v, want %v", handled, want)
+	}
+	if stats := d.stats(); stats.Dropped != 0 {
+		t.Fatalf("stats = %+v, want nothing dropped", stats)
+	}
+}
+
+func TestDispatcherDrop(t *testing.T) {
+	d := newDispatcher(DispatcherOptions{Workers: 1, QueueSize: 1, Overflow: OverflowDrop})
+	defer d.stop()
+
+	release := make(chan struct{})
+	blockDispatcher(t, d, release)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	d.submit(messagePayload(1, 1), wg.Done)
+	d.submit(messagePayload(1, 1), func() {
+		t.Error("dropped task was handled")
+	})
+
+	if stats := d.stats(); stats.Dropped != 1 || stats.QueueDepth != 1 {
+		t.Fatalf("stats = %+v, want 1 dropped and 1 queued", stats)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestDispatcherStop(t *testing.T) {
+	d := newDispatcher(DispatcherOptions{Workers: 2})
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	d.submit(messagePayload(1, 1), wg.Done)
+	d.stop()
+	wg.Wait()
+
+	if d.submit(messagePayload(1, 1), func() {}) {
+		t.Fatal("submit() = true on a stopped dispatcher")
+	}
+}
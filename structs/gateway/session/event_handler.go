@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
@@ -20,6 +21,10 @@ const (
 	ChannelCreateListener              Listener = "CHANNEL_CREATE"
 	ChannelUpdateListener              Listener = "CHANNEL_UPDATE"
 	ChannelDeleteListener              Listener = "CHANNEL_DELETE"
+	ThreadCreateListener               Listener = "THREAD_CREATE"
+	ThreadUpdateListener               Listener = "THREAD_UPDATE"
+	ThreadDeleteListener               Listener = "THREAD_DELETE"
+	ThreadListSyncListener             Listener = "THREAD_LIST_SYNC"
 	GuildCreateListener                Listener = "GUILD_CREATE"
 	GuildUpdateListener                Listener = "GUILD_UPDATE"
 	GuildDeleteListener                Listener = "GUILD_DELETE"
@@ -100,6 +105,25 @@ type eventHandler struct {
 	OpCodeHandlers   map[gateway.GatewayOpCode]CommandFunc
 	CustomHandlers   map[string]CommandFunc
 	ListenerHandlers map[string]CommandFunc
+
+	mwMu        *sync.RWMutex
+	middlewares []Middleware
+
+	colMu       *sync.Mutex
+	collectorID uint64
+	collectors  map[uint64]func(payload.SessionPayload)
+
+	dispMu     *sync.RWMutex
+	dispatcher *dispatcher
+
+	errMu   *sync.RWMutex
+	onError func(ErrorContext)
+
+	routerMu *sync.RWMutex
+	router   *CommandRouter
+
+	chunkMu     *sync.RWMutex
+	chunkGuilds bool
 }
 
 type voiceEventHandler struct {
@@ -152,6 +176,13 @@ func newEventHandler() *eventHandler {
 		},
 		CustomHandlers:   map[string]CommandFunc{},
 		ListenerHandlers: map[string]CommandFunc{},
+		mwMu:             &sync.RWMutex{},
+		colMu:            &sync.Mutex{},
+		dispMu:           &sync.RWMutex{},
+		errMu:            &sync.RWMutex{},
+		routerMu:         &sync.RWMutex{},
+		chunkMu:          &sync.RWMutex{},
+		collectors:       map[uint64]func(payload.SessionPayload){},
 	}
 
 	e.NamedHandlers = map[string]CommandFunc{
@@ -163,6 +194,10 @@ func newEventHandler() *eventHandler {
 		"CHANNEL_CREATE":                handleChannelCreateEvent,
 		"CHANNEL_UPDATE":                handleChannelUpdateEvent,
 		"CHANNEL_DELETE":                handleChannelDeleteEvent,
+		"THREAD_CREATE":                 handleThreadCreateEvent,
+		"THREAD_UPDATE":                 handleThreadUpdateEvent,
+		"THREAD_DELETE":                 handleThreadDeleteEvent,
+		"THREAD_LIST_SYNC":              handleThreadListSyncEvent,
 		"GUILD_CREATE":                  handleGuildCreateEvent,
 		"GUILD_UPDATE":                  handleGuildUpdateEvent,
 		"GUILD_DELETE":                  handleGuildDeleteEvent,
@@ -255,12 +290,7 @@ func (e *eventHandler) HandleEvent(s ClientSession, payload payload.SessionPaylo
 			}
 
 			// let her rip tater chip
-			go func() {
-				if err := handler(s, payload); err != nil {
-					fmt.Printf("ERROR HANDLING OPCODE EVENT: %v, %s, %v\n", payload.OpCode, opCodeNames[payload.OpCode], err)
-					s.Error(err)
-				}
-			}()
+			go e.call(s, payload, handler)
 			return nil
 		}
 		return errors.New("no handler for opcode")
@@ -274,19 +304,34 @@ func (e *eventHandler) HandleEvent(s ClientSession, payload payload.SessionPaylo
 			s.SetSequence(*payload.Seq)
 		}
 
-		// let her rip tater chip
-		go func() {
-			if err := handler(s, payload); err != nil {
-				s.Error(err)
-			}
+		// collectors get the event before it is handed off, so a handler waiting on one can't block itself
+		e.feedCollectors(payload)
+
+		// the interaction handler only dispatches to the custom handlers, which get wrapped themselves
+		if *payload.EventName != "INTERACTION_CREATE" {
+			handler = e.wrap(handler)
+		}
+
+		// read before the event is queued, so the handler of this event can't have overwritten the cache yet
+		// whichever worker picks it up
+		payload := withPreviousState(s, payload)
+
+		task := func() {
+			e.call(s, payload, handler)
 
 			// check if there are any listeners for this event
 			if listener, ok := e.ListenerHandlers[*payload.EventName]; ok && listener != nil {
-				if err := listener(s, payload); err != nil {
-					s.Error(err)
-				}
+				e.call(s, payload, e.wrap(listener))
 			}
-		}()
+		}
+
+		// without a dispatcher every event gets its own goroutine, as does an event racing a dispatcher being replaced
+		if d := e.getDispatcher(); d != nil && d.submit(payload, task) {
+			return nil
+		}
+
+		// let her rip tater chip
+		go task()
 		return nil
 	}
 	return errors.New("no handler for event name")
@@ -0,0 +1,370 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
+)
+
+// CooldownScope decides who shares a cooldown or concurrency limit.
+type CooldownScope int
+
+const (
+	// UserScope limits every user separately.
+	UserScope CooldownScope = iota
+	// GuildScope limits every guild separately, direct messages are limited per user.
+	GuildScope
+	// ChannelScope limits every channel separately.
+	ChannelScope
+	// GlobalScope shares the limit between everyone.
+	GlobalScope
+)
+
+// Cooldown allows a command to be used Uses times every Per.
+type Cooldown struct {
+	Scope CooldownScope
+	// Uses defaults to 1.
+	Uses int
+	Per  time.Duration
+}
+
+// Concurrency limits how many executions of a command can run at the same time.
+type Concurrency struct {
+	Scope CooldownScope
+	Max   int
+}
+
+// Guards are checks that run before a command handler, replying ephemerally to the user when one of them fails.
+// Permission checks run first, then the concurrency limit and the cooldown, so a rejected use never counts towards the cooldown.
+type Guards struct {
+	// MemberPermissions are the permissions the invoking member needs in the channel, i.e `structs.KickMembers | structs.BanMembers`.
+	MemberPermissions structs.Permission
+	// BotPermissions are the permissions the bot needs in the channel.
+	BotPermissions structs.Permission
+	// GuildOnly rejects uses in direct messages.
+	GuildOnly   bool
+	Cooldown    *Cooldown
+	Concurrency *Concurrency
+	// Store keeps track of the cooldowns, defaults to a store shared by every guard in the process.
+	Store CooldownStore
+}
+
+// CooldownStore keeps track of cooldown buckets, implement it on top of a shared database to share cooldowns between processes.
+type CooldownStore interface {
+	// Hit records a use of the bucket, allowing `uses` uses every `per`.
+	// It returns how long until the bucket can be used again when the use was rejected, or zero when it was allowed.
+	Hit(key string, uses int, per time.Duration) (time.Duration, error)
+}
+
+var defaultCooldownStore = NewMemoryCooldownStore()
+
+// Guard wraps the handler with the guards, see `Guards`.
+//
+// Example:
+//
+//	router.Handle("purge", session.Guard(session.Guards{
+//	    MemberPermissions: structs.ManageMessages,
+//	    BotPermissions:    structs.ManageMessages | structs.ReadMessageHistory,
+//	    Cooldown:          &session.Cooldown{Scope: session.ChannelScope, Per: 10 * time.Second},
+//	}, purgeHandler))
+func Guard(guards Guards, handler CommandHandler) CommandHandler {
+	if guards.Store == nil {
+		guards.Store = defaultCooldownStore
+	}
+	limiter := newConcurrencyLimiter()
+
+	return func(ctx *CommandContext) error {
+		if guards.GuildOnly && ctx.GuildID() == nil {
+			return ctx.ReplyEphemeral("This command can only be used in a server.")
+		}
+
+		if guards.MemberPermissions != 0 {
+			if missing := missingPermissions(guards.MemberPermissions, ctx.memberPermissions()); missing != 0 {
+				return ctx.ReplyEphemeral("You need the " + describePermissions(missing) + " to use this command.")
+			}
+		}
+		if guards.BotPermissions != 0 {
+			if missing := missingPermissions(guards.BotPermissions, ctx.botPermissions()); missing != 0 {
+				return ctx.ReplyEphemeral("I need the " + describePermissions(missing) + " to do that.")
+			}
+		}
+
+		if c := guards.Concurrency; c != nil && c.Max > 0 {
+			key := ctx.guardKey(c.Scope)
+			if !limiter.acquire(key, c.Max) {
+				return ctx.ReplyEphemeral("This command is already running, try again once it's done.")
+			}
+			defer limiter.release(key)
+		}
+
+		if c := guards.Cooldown; c != nil && c.Per > 0 {
+			uses := c.Uses
+			if uses <= 0 {
+				uses = 1
+			}
+			wait, err := guards.Store.Hit("cooldown:"+ctx.guardKey(c.Scope), uses, c.Per)
+			if err != nil {
+				return fmt.Errorf("failed to check cooldown: %w", err)
+			}
+			if wait > 0 {
+				return ctx.ReplyEphemeral("Slow down! You can use this command again in " + formatWait(wait) + ".")
+			}
+		}
+
+		return handler(ctx)
+	}
+}
+
+// guardKey identifies the command and the scope the context falls into.
+func (c *CommandContext) guardKey(scope CooldownScope) string {
+	name := c.Path
+	if name == "" {
+		name = c.CustomID()
+	}
+
+	var id string
+	switch scope {
+	case GuildScope:
+		if guildID := c.GuildID(); guildID != nil {
+			id = "guild:" + guildID.ToString()
+			break
+		}
+		fallthrough
+	case UserScope:
+		if author := c.Author(); author != nil {
+			id = "user:" + author.ID.ToString()
+		}
+	case ChannelScope:
+		if channelID := c.ChannelID(); channelID != nil {
+			id = "channel:" + channelID.ToString()
+		}
+	}
+	return name + ":" + id
+}
+
+// memberPermissions returns the permissions of the invoking member in the channel, computed from the cache when possible.
+func (c *CommandContext) memberPermissions() structs.Permission {
+	member := c.Member()
+	if member == nil {
+		return 0
+	}
+
+	if guildID := c.GuildID(); guildID != nil && c.cache() != nil && member.User != nil {
+		if permissions, err := cache.MemberPermissions(c.cache(), *guildID, *member, c.ChannelID()); err == nil {
+			return permissions
+		}
+	}
+	// Discord includes the permissions of the member in the channel, which is all there is without a cache
+	if member.Permissions != nil {
+		return member.Permissions.Value()
+	}
+	return 0
+}
+
+// botPermissions returns the permissions of the bot in the channel, computed from the cache when possible.
+func (c *CommandContext) botPermissions() structs.Permission {
+	if channelID := c.ChannelID(); channelID != nil && c.Session != nil && c.GuildID() != nil {
+		if permissions, err := c.Session.SelfPermissions(*channelID); err == nil {
+			return permissions
+		}
+	}
+	if c.Interaction == nil {
+		return 0
+	}
+	return c.Interaction.AppPermissions.Value()
+}
+
+// cache returns the cache store of the session, nil without a session.
+func (c *CommandContext) cache() cache.CacheStore {
+	if c.Session == nil {
+		return nil
+	}
+	return c.Session.GetCache()
+}
+
+func missingPermissions(required, granted structs.Permission) structs.Permission {
+	if granted&structs.Administrator != 0 {
+		return 0
+	}
+	return required &^ granted
+}
+
+// permissionLabels holds the name the Discord client shows for each permission, indexed by its bit,
+// so the replies of the guards read the same as the settings the user sees.
+var permissionLabels = [...]string{
+	0:  "Create Invite",
+	1:  "Kick Members",
+	2:  "Ban Members",
+	3:  "Administrator",
+	4:  "Manage Channels",
+	5:  "Manage Server",
+	6:  "Add Reactions",
+	7:  "View Audit Log",
+	8:  "Priority Speaker",
+	9:  "Video",
+	10: "View Channel",
+	11: "Send Messages",
+	12: "Send Text-to-Speech Messages",
+	13: "Manage Messages",
+	14: "Embed Links",
+	15: "Attach Files",
+	16: "Read Message History",
+	17: "Mention Everyone",
+	18: "Use External Emojis",
+	19: "View Server Insights",
+	20: "Connect",
+	21: "Speak",
+	22: "Mute Members",
+	23: "Deafen Members",
+	24: "Move Members",
+	25: "Use Voice Activity",
+	26: "Change Nickname",
+	27: "Manage Nicknames",
+	28: "Manage Roles",
+	29: "Manage Webhooks",
+	30: "Manage Expressions",
+	31: "Use Application Commands",
+	32: "Request to Speak",
+	33: "Manage Events",
+	34: "Manage Threads",
+	35: "Create Public Threads",
+	36: "Create Private Threads",
+	37: "Use External Stickers",
+	38: "Send Messages in Threads",
+	39: "Use Activities",
+	40: "Timeout Members",
+	41: "View Creator Monetization Analytics",
+	42: "Use Soundboard",
+	43: "Create Expressions",
+	44: "Create Events",
+	45: "Use External Sounds",
+	46: "Send Voice Messages",
+	49: "Create Polls",
+	50: "Use External Apps",
+}
+
+func describePermissions(permissions structs.Permission) string {
+	var names []string
+	for bit, label := range permissionLabels {
+		if permissions&(1<<bit) != 0 && label != "" {
+			names = append(names, label)
+		}
+	}
+	if len(names) == 1 {
+		return names[0] + " permission"
+	}
+	return strings.Join(names, ", ") + " permissions"
+}
+
+// formatWait writes the wait out in words, i.e `1 hour 5 minutes` or `2 minutes 30 seconds`, seconds are left out past an hour.
+func formatWait(wait time.Duration) string {
+	if wait <= time.Second {
+		return "a second"
+	}
+	wait = wait.Round(time.Second)
+	if wait >= time.Hour {
+		wait = wait.Round(time.Minute)
+	}
+
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+	var parts []string
+	for _, unit := range units {
+		n := int(wait / unit.size)
+		wait -= time.Duration(n) * unit.size
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+unit.name)
+		case n > 1:
+			parts = append(parts, strconv.Itoa(n)+" "+unit.name+"s")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type concurrencyLimiter struct {
+	mu      *sync.Mutex
+	running map[string]int
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{
+		mu:      &sync.Mutex{},
+		running: make(map[string]int),
+	}
+}
+
+func (l *concurrencyLimiter) acquire(key string, max int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[key] >= max {
+		return false
+	}
+	l.running[key]++
+	return true
+}
+
+func (l *concurrencyLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[key]--; l.running[key] <= 0 {
+		delete(l.running, key)
+	}
+}
+
+// MemoryCooldownStore keeps cooldowns in memory, it is only shared by the guards of a single process.
+type MemoryCooldownStore struct {
+	mu        *sync.Mutex
+	buckets   map[string]*cooldownBucket
+	lastSweep time.Time
+}
+
+type cooldownBucket struct {
+	uses  int
+	reset time.Time
+}
+
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{
+		mu:        &sync.Mutex{},
+		buckets:   make(map[string]*cooldownBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryCooldownStore) Hit(key string, uses int, per time.Duration) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, bucket := range m.buckets {
+			if !now.Before(bucket.reset) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	bucket, ok := m.buckets[key]
+	if !ok || !now.Before(bucket.reset) {
+		m.buckets[key] = &cooldownBucket{uses: 1, reset: now.Add(per)}
+		return 0, nil
+	}
+	if bucket.uses >= uses {
+		return bucket.reset.Sub(now), nil
+	}
+	bucket.uses++
+	return 0, nil
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// heartbeatTracker matches the heartbeats sent on a connection with their ACKs, to measure the latency
+// and to notice a zombie connection, one that stopped answering without closing.
+type heartbeatTracker struct {
+	mu      *sync.Mutex
+	sentAt  time.Time
+	pending bool
+	latency time.Duration
+}
+
+func newHeartbeatTracker() *heartbeatTracker {
+	return &heartbeatTracker{
+		mu: &sync.Mutex{},
+	}
+}
+
+// sent records a heartbeat written to the connection, the methods of a nil tracker do nothing.
+func (h *heartbeatTracker) sent() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sentAt = time.Now()
+	h.pending = true
+}
+
+// acked records the ACK of the last heartbeat, and measures its round trip.
+func (h *heartbeatTracker) acked() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.pending {
+		return
+	}
+	h.latency = time.Since(h.sentAt)
+	h.pending = false
+}
+
+// missed reports whether the last heartbeat is still waiting for its ACK.
+func (h *heartbeatTracker) missed() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pending
+}
+
+func (h *heartbeatTracker) getLatency() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latency
+}
+
+// Latency returns the round trip of the last acknowledged heartbeat, 0 until the first ACK is received.
+func (s *clientSession) Latency() time.Duration {
+	return s.heartbeat.getLatency()
+}
+
+// heartbeatOf returns the tracker of the session, nil when the session isn't implemented by this package.
+func heartbeatOf(s ClientSession) *heartbeatTracker {
+	if sess, ok := s.(*clientSession); ok {
+		return sess.heartbeat
+	}
+	return nil
+}
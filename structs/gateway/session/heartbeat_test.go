@@ -0,0 +1,51 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatTracker(t *testing.T) {
+	h := newHeartbeatTracker()
+	if h.missed() || h.getLatency() != 0 {
+		t.Fatal("new tracker has a heartbeat pending or a latency")
+	}
+
+	// an ACK without a heartbeat, like the one answering a heartbeat requested by Discord, isn't measured
+	h.acked()
+	if h.getLatency() != 0 {
+		t.Fatalf("latency = %v, want 0 before any heartbeat", h.getLatency())
+	}
+
+	h.sent()
+	if !h.missed() {
+		t.Fatal("heartbeat isn't pending after it was sent")
+	}
+	time.Sleep(10 * time.Millisecond)
+	h.acked()
+	if h.missed() {
+		t.Fatal("heartbeat still pending after its ACK")
+	}
+	latency := h.getLatency()
+	if latency < 10*time.Millisecond {
+		t.Fatalf("latency = %v, want at least the 10ms waited", latency)
+	}
+
+	// a second ACK for the same heartbeat keeps the latency measured by the first
+	h.acked()
+	if h.getLatency() != latency {
+		t.Fatalf("latency = %v after a repeated ACK, want %v", h.getLatency(), latency)
+	}
+}
+
+func TestHeartbeatTrackerNil(t *testing.T) {
+	var h *heartbeatTracker
+	h.sent()
+	h.acked()
+	if h.missed() {
+		t.Fatal("nil tracker reports a missed heartbeat")
+	}
+	if heartbeatOf(newTestSession()) != nil {
+		t.Fatal("heartbeatOf() returned a tracker for a session of another package")
+	}
+}
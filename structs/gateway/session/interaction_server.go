@@ -0,0 +1,325 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util"
+	requestutil "github.com/Carmen-Shannon/simple-discord/util/request_util"
+)
+
+const (
+	// Discord gives up on the request after 3 seconds, this leaves some room for the response to get there
+	defaultInteractionResponseTimeout = 2500 * time.Millisecond
+	maxInteractionBodySize            = 1 << 20
+)
+
+// InteractionServerOptions configures an InteractionServer.
+type InteractionServerOptions struct {
+	// ResponseTimeout is how long the server waits for the handler to respond before deferring the interaction, defaults to 2.5 seconds.
+	// Once deferred, message responses from the handler are sent as edits of the original response instead.
+	// The deferral is only visible to the invoking user if the handler called `CommandContext.PreferEphemeral` before it,
+	// and autocomplete interactions, which can't be deferred, are answered with no choices instead.
+	ResponseTimeout time.Duration
+}
+
+// InteractionServer is an http.Handler receiving interactions through Discord's outgoing webhooks instead of the gateway.
+// Requests are verified against the application's public key, and interactions are dispatched into the same CommandRouter
+// used by the gateway, with the first response of the handler becoming the HTTP response.
+//
+// There is no gateway connection behind these interactions, so `CommandContext.Session` is nil in handlers run by the server.
+type InteractionServer struct {
+	publicKey ed25519.PublicKey
+	router    *CommandRouter
+	opts      InteractionServerOptions
+	errMu     *sync.RWMutex
+	onError   func(ErrorContext)
+}
+
+// NewInteractionServer creates an InteractionServer for the application with the hex encoded public key,
+// as shown on the General Information page of the application.
+//
+// Example:
+//
+//	server, err := session.NewInteractionServer(os.Getenv("DISCORD_PUBLIC_KEY"), router, session.InteractionServerOptions{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	http.Handle("/interactions", server)
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+func NewInteractionServer(publicKey string, router *CommandRouter, opts InteractionServerOptions) (*InteractionServer, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("public key must be a hex encoded ed25519 key")
+	}
+	if router == nil {
+		return nil, errors.New("interaction server needs a command router")
+	}
+	if opts.ResponseTimeout <= 0 {
+		opts.ResponseTimeout = defaultInteractionResponseTimeout
+	}
+
+	return &InteractionServer{
+		publicKey: ed25519.PublicKey(key),
+		router:    router,
+		opts:      opts,
+		errMu:     &sync.RWMutex{},
+	}, nil
+}
+
+// OnError sets the callback receiving every error returned by, and every panic recovered from, a handler run by the server.
+// The Shard of the ErrorContext is always -1, since the interaction didn't come in on a shard.
+func (s *InteractionServer) OnError(handler func(ErrorContext)) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.onError = handler
+}
+
+func (s *InteractionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInteractionBodySize))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	// Discord periodically sends requests with a bad signature, and removes the endpoint if they are accepted
+	if !s.verify(r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction structs.Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	if interaction.Type == structs.PingInteraction {
+		writeInteractionResponse(w, &structs.InteractionResponse{Type: structs.PongInteraction})
+		return
+	}
+
+	p := payload.SessionPayload{
+		EventName: util.ToPtr("INTERACTION_CREATE"),
+		Data:      receiveevents.InteractionCreateEvent{Interaction: &interaction},
+	}
+	ctx, responder := newHTTPInteractionContext(p, &interaction)
+	ctx.translations = s.router.Translations()
+
+	handler, ok := s.router.route(ctx)
+	if !ok {
+		s.report(p, fmt.Errorf("no handler for interaction %q", interactionName(&interaction)), nil, nil)
+		http.Error(w, "no handler for interaction", http.StatusNotFound)
+		return
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.call(p, func() error { return handler(ctx) })
+	}()
+
+	timeout := time.NewTimer(s.opts.ResponseTimeout)
+	defer timeout.Stop()
+
+	select {
+	case response := <-responder.responses:
+		writeInteractionResponse(w, response.InteractionResponse())
+	case err := <-done:
+		// the handler could have responded right before returning
+		select {
+		case response := <-responder.responses:
+			writeInteractionResponse(w, response.InteractionResponse())
+			return
+		default:
+		}
+		if err == nil {
+			s.report(p, fmt.Errorf("handler for interaction %q returned without responding", interactionName(&interaction)), nil, nil)
+		}
+		http.Error(w, "interaction handler failed", http.StatusInternalServerError)
+	case <-timeout.C:
+		if response, ok := responder.deferResponse(); ok {
+			writeInteractionResponse(w, response)
+		} else {
+			writeInteractionResponse(w, (<-responder.responses).InteractionResponse())
+		}
+	case <-r.Context().Done():
+	}
+}
+
+func (s *InteractionServer) verify(signature, timestamp string, body []byte) bool {
+	if signature == "" || timestamp == "" {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(s.publicKey, append([]byte(timestamp), body...), sig)
+}
+
+// call runs the handler, recovering from any panic and reporting anything that went wrong.
+func (s *InteractionServer) call(p payload.SessionPayload, handler func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			err = fmt.Errorf("panic handling event %s: %v", eventName(p), r)
+			s.report(p, err, r, stack)
+		}
+	}()
+
+	if err = handler(); err != nil {
+		s.report(p, err, nil, nil)
+	}
+	return err
+}
+
+func (s *InteractionServer) report(p payload.SessionPayload, err error, recovered any, stack []byte) {
+	if recovered != nil {
+		log.Printf("interaction server: %v\n%s", err, stack)
+	} else {
+		log.Printf("interaction server: %v", err)
+	}
+
+	s.errMu.RLock()
+	onError := s.onError
+	s.errMu.RUnlock()
+	if onError == nil {
+		return
+	}
+
+	ctx := ErrorContext{
+		Shard:     -1,
+		EventName: eventName(p),
+		Payload:   p,
+		Err:       err,
+		Panic:     recovered,
+		Stack:     stack,
+	}
+	if ev, ok := p.Data.(receiveevents.InteractionCreateEvent); ok {
+		ctx.Interaction = ev.Interaction
+		ctx.GuildID = ev.GuildID
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in error callback: %v\n%s", r, debug.Stack())
+		}
+	}()
+	onError(ctx)
+}
+
+// httpResponder hands the first response of a handler to the HTTP request, anything after that goes through the webhook.
+type httpResponder struct {
+	mu          *sync.Mutex
+	answered    bool
+	ephemeral   bool
+	responses   chan structs.InteractionResponseOptions
+	interaction *structs.Interaction
+}
+
+func newHTTPInteractionContext(p payload.SessionPayload, interaction *structs.Interaction) (*CommandContext, *httpResponder) {
+	responder := &httpResponder{
+		mu:          &sync.Mutex{},
+		responses:   make(chan structs.InteractionResponseOptions, 1),
+		interaction: interaction,
+	}
+
+	path, options := commandPath(interaction.Data)
+	return &CommandContext{
+		Payload:     p,
+		Interaction: interaction,
+		Path:        path,
+		Options:     options,
+		respond:     responder.respond,
+		ephemeral:   responder.preferEphemeral,
+	}, responder
+}
+
+func (h *httpResponder) respond(response structs.InteractionResponseOptions) error {
+	h.mu.Lock()
+	if !h.answered {
+		h.answered = true
+		h.mu.Unlock()
+		h.responses <- response
+		return nil
+	}
+	h.mu.Unlock()
+
+	// the interaction was acknowledged already, so a message can only replace the original response
+	res := response.InteractionResponse()
+	if res.Type != structs.ChannelMessageWithSourceInteraction && res.Type != structs.UpdateMessageInteraction {
+		return errors.New("interaction was already responded to")
+	}
+
+	var data structs.InteractionResponseData
+	if res.Data != nil {
+		data = *res.Data
+	}
+	_, err := requestutil.EditOriginalInteractionResponse(h.interaction.ApplicationID.ToString(), h.interaction.Token, data)
+	return err
+}
+
+func (h *httpResponder) preferEphemeral() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ephemeral = true
+}
+
+// deferResponse acknowledges the interaction on behalf of a slow handler, returning false if the handler responded in the meantime.
+func (h *httpResponder) deferResponse() (*structs.InteractionResponse, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.answered {
+		return nil, false
+	}
+	h.answered = true
+
+	switch h.interaction.Type {
+	case structs.ApplicationCommandAutocompleteInteraction:
+		// there is no deferred autocomplete result, the suggestions are lost if they aren't sent in time
+		return &structs.InteractionResponse{Type: structs.ApplicationCommandAutocompleteResultInteraction}, true
+	case structs.MessageComponentInteraction, structs.ModalSubmitInteraction:
+		// a deferred update edits the message the component is on, which keeps its own visibility
+		return &structs.InteractionResponse{Type: structs.DeferredUpdatedMessageInteraction}, true
+	}
+
+	response := &structs.InteractionResponse{Type: structs.DeferredChannelMessageWithSourceInteraction}
+	if h.ephemeral {
+		response.Data = &structs.InteractionResponseData{Flags: structs.NewBitfield(structs.EphemeralMessageFlag)}
+	}
+	return response, true
+}
+
+func writeInteractionResponse(w http.ResponseWriter, response *structs.InteractionResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("interaction server: failed to write response: %v", err)
+	}
+}
+
+func interactionName(interaction *structs.Interaction) string {
+	if interaction.Data == nil {
+		return ""
+	}
+	if interaction.Data.CustomID != "" {
+		return interaction.Data.CustomID
+	}
+	return interaction.Data.Name
+}
@@ -0,0 +1,278 @@
+package session
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+const testTimestamp = "1700000000"
+
+type testInteractionServer struct {
+	*InteractionServer
+	privateKey ed25519.PrivateKey
+}
+
+func newTestInteractionServer(t *testing.T, router *CommandRouter, opts InteractionServerOptions) *testInteractionServer {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if router == nil {
+		router = NewCommandRouter()
+	}
+	server, err := NewInteractionServer(hex.EncodeToString(publicKey), router, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testInteractionServer{InteractionServer: server, privateKey: privateKey}
+}
+
+func (s *testInteractionServer) sign(timestamp string, body []byte) string {
+	return hex.EncodeToString(ed25519.Sign(s.privateKey, append([]byte(timestamp), body...)))
+}
+
+// serve sends the body signed with the timestamp, the headers override the signed ones.
+func (s *testInteractionServer) serve(method string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/interactions", bytes.NewReader(body))
+	req.Header.Set("X-Signature-Ed25519", s.sign(testTimestamp, body))
+	req.Header.Set("X-Signature-Timestamp", testTimestamp)
+	for name, value := range headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func interactionBody(t *testing.T, interaction map[string]any) []byte {
+	t.Helper()
+	interaction["id"] = "1"
+	interaction["application_id"] = "2"
+	interaction["token"] = "token"
+	body, err := json.Marshal(interaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) structs.InteractionResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", contentType)
+	}
+	var response structs.InteractionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response body %q: %v", rec.Body.String(), err)
+	}
+	return response
+}
+
+func TestInteractionServerPing(t *testing.T) {
+	server := newTestInteractionServer(t, nil, InteractionServerOptions{})
+	rec := server.serve(http.MethodPost, interactionBody(t, map[string]any{"type": structs.PingInteraction}), nil)
+
+	if response := decodeResponse(t, rec); response.Type != structs.PongInteraction {
+		t.Fatalf("response type = %d, want %d", response.Type, structs.PongInteraction)
+	}
+}
+
+func TestInteractionServerRejectsBadSignatures(t *testing.T) {
+	server := newTestInteractionServer(t, nil, InteractionServerOptions{})
+	body := interactionBody(t, map[string]any{"type": structs.PingInteraction})
+	other := newTestInteractionServer(t, nil, InteractionServerOptions{})
+
+	tests := []struct {
+		name    string
+		body    []byte
+		headers map[string]string
+	}{
+		{name: "missing signature", body: body, headers: map[string]string{"X-Signature-Ed25519": ""}},
+		{name: "missing timestamp", body: body, headers: map[string]string{"X-Signature-Timestamp": ""}},
+		{name: "signature not hex", body: body, headers: map[string]string{"X-Signature-Ed25519": "not-a-signature"}},
+		{name: "signature too short", body: body, headers: map[string]string{"X-Signature-Ed25519": hex.EncodeToString([]byte("short"))}},
+		{name: "signed by another key", body: body, headers: map[string]string{"X-Signature-Ed25519": other.sign(testTimestamp, body)}},
+		{name: "tampered body", body: append(bytes.Clone(body), ' '), headers: map[string]string{"X-Signature-Ed25519": server.sign(testTimestamp, body)}},
+		{name: "tampered timestamp", body: body, headers: map[string]string{"X-Signature-Timestamp": "1700000001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := server.serve(http.MethodPost, tt.body, tt.headers)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestInteractionServerRejectsOtherMethods(t *testing.T) {
+	server := newTestInteractionServer(t, nil, InteractionServerOptions{})
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			rec := server.serve(method, nil, nil)
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+			}
+		})
+	}
+}
+
+func TestInteractionServerHandlerResponse(t *testing.T) {
+	router := NewCommandRouter()
+	router.Handle("ping", func(ctx *CommandContext) error {
+		return ctx.Reply("pong")
+	})
+	server := newTestInteractionServer(t, router, InteractionServerOptions{})
+
+	body := interactionBody(t, map[string]any{
+		"type": structs.ApplicationCommandInteraction,
+		"data": map[string]any{"id": "3", "name": "ping", "type": structs.ChatInputCommand},
+	})
+	response := decodeResponse(t, server.serve(http.MethodPost, body, nil))
+
+	if response.Type != structs.ChannelMessageWithSourceInteraction {
+		t.Fatalf("response type = %d, want %d", response.Type, structs.ChannelMessageWithSourceInteraction)
+	}
+	if response.Data == nil || response.Data.Content != "pong" {
+		t.Fatalf("response data = %+v, want the content pong", response.Data)
+	}
+}
+
+func TestInteractionServerDefersSlowHandlers(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := func(ctx *CommandContext) error {
+		<-release
+		return nil
+	}
+
+	router := NewCommandRouter()
+	router.Handle("slow", slow)
+	router.Handle("private", func(ctx *CommandContext) error {
+		ctx.PreferEphemeral()
+		return slow(ctx)
+	})
+	router.Autocomplete("slow", "query", func(ctx *CommandContext, focused string) ([]structs.ApplicationCommandOptionChoice, error) {
+		<-release
+		return nil, nil
+	})
+	if err := router.Component("slow-button", slow); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Modal("slow-modal", slow); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestInteractionServer(t, router, InteractionServerOptions{ResponseTimeout: 10 * time.Millisecond})
+
+	tests := []struct {
+		name        string
+		interaction map[string]any
+		want        structs.InteractionResponseType
+		ephemeral   bool
+	}{
+		{
+			name: "command",
+			interaction: map[string]any{
+				"type": structs.ApplicationCommandInteraction,
+				"data": map[string]any{"id": "3", "name": "slow", "type": structs.ChatInputCommand},
+			},
+			want: structs.DeferredChannelMessageWithSourceInteraction,
+		},
+		{
+			name: "ephemeral command",
+			interaction: map[string]any{
+				"type": structs.ApplicationCommandInteraction,
+				"data": map[string]any{"id": "4", "name": "private", "type": structs.ChatInputCommand},
+			},
+			want:      structs.DeferredChannelMessageWithSourceInteraction,
+			ephemeral: true,
+		},
+		{
+			name: "autocomplete is answered instead of deferred",
+			interaction: map[string]any{
+				"type": structs.ApplicationCommandAutocompleteInteraction,
+				"data": map[string]any{
+					"id":      "3",
+					"name":    "slow",
+					"type":    structs.ChatInputCommand,
+					"options": []map[string]any{{"name": "query", "type": structs.StringOptionType, "value": "zz", "focused": true}},
+				},
+			},
+			want: structs.ApplicationCommandAutocompleteResultInteraction,
+		},
+		{
+			name: "component",
+			interaction: map[string]any{
+				"type": structs.MessageComponentInteraction,
+				"data": map[string]any{"custom_id": "slow-button", "component_type": 2},
+			},
+			want: structs.DeferredUpdatedMessageInteraction,
+		},
+		{
+			name: "modal",
+			interaction: map[string]any{
+				"type": structs.ModalSubmitInteraction,
+				"data": map[string]any{"custom_id": "slow-modal"},
+			},
+			want: structs.DeferredUpdatedMessageInteraction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := decodeResponse(t, server.serve(http.MethodPost, interactionBody(t, tt.interaction), nil))
+			if response.Type != tt.want {
+				t.Fatalf("response type = %d, want %d", response.Type, tt.want)
+			}
+			ephemeral := response.Data != nil && response.Data.Flags.Has(structs.EphemeralMessageFlag)
+			if ephemeral != tt.ephemeral {
+				t.Fatalf("ephemeral = %v, want %v", ephemeral, tt.ephemeral)
+			}
+		})
+	}
+}
+
+func TestInteractionServerAutocompleteWithoutChoices(t *testing.T) {
+	router := NewCommandRouter()
+	router.Autocomplete("search", "query", func(ctx *CommandContext, focused string) ([]structs.ApplicationCommandOptionChoice, error) {
+		return nil, nil
+	})
+	server := newTestInteractionServer(t, router, InteractionServerOptions{})
+
+	body := interactionBody(t, map[string]any{
+		"type": structs.ApplicationCommandAutocompleteInteraction,
+		"data": map[string]any{
+			"id":      "3",
+			"name":    "search",
+			"type":    structs.ChatInputCommand,
+			"options": []map[string]any{{"name": "query", "type": structs.StringOptionType, "value": "zz", "focused": true}},
+		},
+	})
+	rec := server.serve(http.MethodPost, body, nil)
+
+	// Discord rejects an autocomplete result without the choices, even when there are none
+	if got, want := strings.TrimSpace(rec.Body.String()), `{"type":8,"data":{"choices":[]}}`; got != want {
+		t.Fatalf("response body = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/util/i18n"
+)
+
+// SetTranslations sets the bundle used by `CommandContext.T`, and to fill in the localizations of the declared commands when syncing.
+//
+// Command names and descriptions are looked up with these keys, for options of subcommands the `options.<name>` part repeats:
+//
+//	commands.<command>.name
+//	commands.<command>.description
+//	commands.<command>.options.<option>.name
+//	commands.<command>.options.<option>.description
+//	commands.<command>.options.<option>.choices.<choice>
+//
+// Localizations set on the command itself take priority over the bundle.
+func (r *CommandRouter) SetTranslations(bundle *i18n.Bundle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.translations = bundle
+}
+
+// Translations returns the bundle set with `SetTranslations`, nil if there is none.
+func (r *CommandRouter) Translations() *i18n.Bundle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.translations
+}
+
+// Locale returns the locale of the user that invoked the interaction, falling back to the locale of the guild.
+func (c *CommandContext) Locale() string {
+	if c.Interaction == nil {
+		return ""
+	}
+	if c.Interaction.Locale != nil && *c.Interaction.Locale != "" {
+		return *c.Interaction.Locale
+	}
+	if c.Interaction.GuildLocale != nil {
+		return *c.Interaction.GuildLocale
+	}
+	return ""
+}
+
+// T translates the key to the locale of the user that invoked the interaction, formatting the message with the args
+// the same way as fmt.Sprintf. Messages missing from the user's locale are looked up in the guild's locale, then in the
+// fallbacks of the bundle set with `CommandRouter.SetTranslations`. Without a bundle, or a message for the key, the key is returned.
+// Text commands have no locale, they use the bundle set with `TextCommandRouter.SetTranslations` and its fallback locale.
+//
+// Example:
+//
+//	return ctx.Reply(ctx.T("ban.success", member.User.Username))
+func (c *CommandContext) T(key string, args ...any) string {
+	if c.translations == nil {
+		return key
+	}
+
+	locales := []string{c.Locale()}
+	if c.Interaction != nil && c.Interaction.GuildLocale != nil {
+		locales = append(locales, *c.Interaction.GuildLocale)
+	}
+	message, ok := c.translations.Message(key, locales...)
+	if !ok {
+		return key
+	}
+	return i18n.Format(message, args...)
+}
+
+// localizeCommand fills the localizations of the command and its options from the bundle, without touching the original.
+func localizeCommand(bundle *i18n.Bundle, command Command) Command {
+	prefix := "commands." + command.Name
+	command.NameLocalizations = mergeLocalizations(bundle, prefix+".name", command.NameLocalizations)
+	// context-menu commands have no description
+	if command.Type == structs.ChatInputCommand {
+		command.DescriptionLocalizations = mergeLocalizations(bundle, prefix+".description", command.DescriptionLocalizations)
+	}
+	command.Options = localizeOptions(bundle, prefix, command.Options)
+	return command
+}
+
+func localizeOptions(bundle *i18n.Bundle, prefix string, options []structs.ApplicationCommandOption) []structs.ApplicationCommandOption {
+	if options == nil {
+		return nil
+	}
+
+	localized := make([]structs.ApplicationCommandOption, len(options))
+	for i, option := range options {
+		key := prefix + ".options." + option.Name
+		option.NameLocalizations = mergeLocalizations(bundle, key+".name", option.NameLocalizations)
+		option.DescriptionLocalizations = mergeLocalizations(bundle, key+".description", option.DescriptionLocalizations)
+
+		if option.Choices != nil {
+			choices := make([]structs.ApplicationCommandOptionChoice, len(option.Choices))
+			for j, choice := range option.Choices {
+				choice.NameLocalizations = mergeLocalizations(bundle, fmt.Sprintf("%s.choices.%s", key, choice.Name), choice.NameLocalizations)
+				choices[j] = choice
+			}
+			option.Choices = choices
+		}
+		option.Options = localizeOptions(bundle, key, option.Options)
+		localized[i] = option
+	}
+	return localized
+}
+
+// mergeLocalizations adds the translations of the key to the localizations, keeping the ones already set.
+func mergeLocalizations(bundle *i18n.Bundle, key string, localizations map[string]string) map[string]string {
+	translations := bundle.Localizations(key)
+	if len(translations) == 0 {
+		return localizations
+	}
+	maps.Copy(translations, localizations)
+	return translations
+}
@@ -0,0 +1,279 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	sendevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/send_events"
+)
+
+var ErrMemberRequestTimeout = errors.New("guild members request timed out")
+
+// memberRequestTimeout is how long `RequestGuildMembers` waits for the chunks when the context has no deadline.
+const memberRequestTimeout = 30 * time.Second
+
+// maxMemberRequestUserIDs is the most user IDs Discord accepts in a single request.
+const maxMemberRequestUserIDs = 100
+
+// memberRequestNonce makes the nonce of every request unique in the process, the chunks echo it back.
+var memberRequestNonce atomic.Uint64
+
+// GuildMembersRequest selects the members `RequestGuildMembers` asks Discord for.
+// Set either Query or UserIDs, a zero value requests every member of the guild.
+type GuildMembersRequest struct {
+	// Query matches the members whose username or nickname starts with it, an empty query matches every member.
+	Query string
+	// Limit is the most members to return for the query, 0 for no limit.
+	// Requesting every member with an empty query needs the GUILD_MEMBERS intent.
+	Limit int
+	// UserIDs requests specific members, up to 100.
+	UserIDs []structs.Snowflake
+	// Presences also returns the presences of the members, this needs the GUILD_PRESENCES intent.
+	Presences bool
+}
+
+// GuildMembersResult holds every chunk Discord sent back for a request, merged in order.
+type GuildMembersResult struct {
+	Members   []structs.GuildMember
+	Presences []structs.PresenceUpdate
+	// NotFound are the requested user IDs that aren't members of the guild.
+	NotFound []structs.Snowflake
+}
+
+// memberRequest aggregates the GUILD_MEMBERS_CHUNK events answering a single request.
+type memberRequest struct {
+	nonce string
+
+	mu     *sync.Mutex
+	chunks map[int]receiveevents.GuildMembersChunk
+	count  int
+
+	done chan struct{}
+	once *sync.Once
+}
+
+// offer is registered as a collector on the event handler, it must never block.
+func (r *memberRequest) offer(p payload.SessionPayload) {
+	chunk, ok := p.Data.(receiveevents.GuildMembersChunk)
+	if !ok || chunk.Nonce == nil || *chunk.Nonce != r.nonce {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chunks[chunk.ChunkIndex] = chunk
+	r.count = chunk.ChunkCount
+	if len(r.chunks) >= r.count {
+		r.once.Do(func() {
+			close(r.done)
+		})
+	}
+}
+
+// result merges the chunks received in the order Discord numbered them.
+func (r *memberRequest) result() *GuildMembersResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	indexes := make([]int, 0, len(r.chunks))
+	for index := range r.chunks {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	result := &GuildMembersResult{}
+	for _, index := range indexes {
+		chunk := r.chunks[index]
+		result.Members = append(result.Members, chunk.Members...)
+		result.NotFound = append(result.NotFound, chunk.NotFound...)
+		for _, presence := range chunk.Presences {
+			if presence.PresenceUpdate != nil {
+				result.Presences = append(result.Presences, *presence.PresenceUpdate)
+			}
+		}
+	}
+	return result
+}
+
+// RequestGuildMembers asks the gateway for members of the guild and waits for every chunk of the answer.
+// The members are added to the cache as the chunks arrive, like any other GUILD_MEMBERS_CHUNK event.
+// When the context has no deadline the request times out after 30 seconds.
+//
+// Parameters:
+//   - ctx: Cancels the wait for the chunks.
+//   - guildID: The ID of a guild on the shard of this session.
+//   - request: The members to request.
+//
+// Returns:
+//   - *GuildMembersResult: Every member Discord returned, with their presences when requested.
+//   - error: ErrMemberRequestTimeout if the chunks didn't all arrive in time, or why the request couldn't be sent.
+//
+// Example:
+//
+//	result, err := sess.RequestGuildMembers(ctx, guildID, session.GuildMembersRequest{
+//	    UserIDs: []structs.Snowflake{userID},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Println(len(result.Members), "members found")
+func (s *clientSession) RequestGuildMembers(ctx context.Context, guildID structs.Snowflake, request GuildMembersRequest) (*GuildMembersResult, error) {
+	event, err := newRequestGuildMembersEvent(s, guildID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := strconv.FormatUint(memberRequestNonce.Add(1), 36)
+	event.Nonce = &nonce
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, memberRequestTimeout)
+		defer cancel()
+	}
+
+	// the request is registered before sending, so no chunk can be missed
+	req := &memberRequest{
+		nonce:  nonce,
+		mu:     &sync.Mutex{},
+		chunks: map[int]receiveevents.GuildMembersChunk{},
+		done:   make(chan struct{}),
+		once:   &sync.Once{},
+	}
+	eh := s.GetEventHandler()
+	id := eh.nextCollectorID()
+	eh.addCollector(id, req.offer)
+	defer eh.removeCollector(id)
+
+	if err := requestGuildMembers(s, event); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-req.done:
+		return req.result(), nil
+	case <-s.GetCtx().Done():
+		return nil, ErrSessionClosed
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrMemberRequestTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// SetChunkGuilds requests every member of the large guilds as they become available after READY,
+// since Discord only sends the online members of guilds over the large threshold.
+// The chunks are only added to the cache, and this needs the GUILD_MEMBERS intent.
+func (s *clientSession) SetChunkGuilds(chunk bool) {
+	s.GetEventHandler().SetChunkGuilds(chunk)
+}
+
+func (e *eventHandler) SetChunkGuilds(chunk bool) {
+	e.chunkMu.Lock()
+	defer e.chunkMu.Unlock()
+	e.chunkGuilds = chunk
+}
+
+func (e *eventHandler) getChunkGuilds() bool {
+	e.chunkMu.RLock()
+	defer e.chunkMu.RUnlock()
+	return e.chunkGuilds
+}
+
+// chunkGuild requests every member of a large guild without waiting for the chunks, the chunk handler caches them.
+func chunkGuild(s ClientSession, server structs.Server) {
+	if !server.Large || server.Guild == nil || !s.GetEventHandler().getChunkGuilds() {
+		return
+	}
+
+	event, err := newRequestGuildMembersEvent(s, server.ID, GuildMembersRequest{})
+	if err == nil {
+		err = requestGuildMembers(s, event)
+	}
+	if err != nil {
+		log.Printf("failed to request the members of guild %s: %v", server.ID.ToString(), err)
+	}
+}
+
+// newRequestGuildMembersEvent validates the request against the limits of the gateway and the intents of the session.
+func newRequestGuildMembersEvent(s ClientSession, guildID structs.Snowflake, request GuildMembersRequest) (sendevents.RequestGuildMembersEvent, error) {
+	event := sendevents.RequestGuildMembersEvent{
+		GuildID:   guildID,
+		Limit:     request.Limit,
+		Presences: request.Presences,
+	}
+
+	// Discord only answers for guilds on the shard the request was sent on, the chunks would never arrive
+	if !onShard(s, guildID) {
+		return event, fmt.Errorf("guild %s isn't on shard %d", guildID.ToString(), *s.GetShard())
+	}
+
+	if len(request.UserIDs) > 0 {
+		if request.Query != "" {
+			return event, errors.New("can't request members by query and user IDs at once")
+		}
+		if len(request.UserIDs) > maxMemberRequestUserIDs {
+			return event, errors.New("can't request more than 100 members by user ID")
+		}
+		event.UserIDs = request.UserIDs
+	} else {
+		if request.Limit < 0 {
+			return event, errors.New("limit can't be negative")
+		}
+		if request.Query == "" && request.Limit == 0 && !hasIntent(s, structs.GuildMembersIntent) {
+			return event, errors.New("requesting every member needs the GUILD_MEMBERS intent")
+		}
+		event.Query = &request.Query
+	}
+
+	if request.Presences && !hasIntent(s, structs.GuildPresencesIntent) {
+		return event, errors.New("requesting presences needs the GUILD_PRESENCES intent")
+	}
+	return event, nil
+}
+
+// requestGuildMembers writes the request to the connection directly, so the error isn't lost in an opcode handler.
+func requestGuildMembers(s ClientSession, event sendevents.RequestGuildMembersEvent) error {
+	requestPayload := payload.SessionPayload{
+		OpCode: gateway.GatewayOpRequestGuildMembers,
+		Data:   event,
+	}
+	requestData, err := json.Marshal(requestPayload)
+	if err != nil {
+		return err
+	}
+
+	s.Write(requestData, false)
+	return nil
+}
+
+// onShard reports whether the guild's events are sent to the shard of the session, always true when it isn't sharded.
+func onShard(s ClientSession, guildID structs.Snowflake) bool {
+	shard, shards := s.GetShard(), s.GetShards()
+	if shard == nil || shards == nil || *shards <= 1 {
+		return true
+	}
+	return int((guildID.ID>>22)%uint64(*shards)) == *shard
+}
+
+func hasIntent(s ClientSession, intent structs.Intent) bool {
+	for _, i := range s.GetIntents() {
+		if i == intent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package session
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util"
+)
+
+func newTestMemberRequest(nonce string) *memberRequest {
+	return &memberRequest{
+		nonce:  nonce,
+		mu:     &sync.Mutex{},
+		chunks: map[int]receiveevents.GuildMembersChunk{},
+		done:   make(chan struct{}),
+		once:   &sync.Once{},
+	}
+}
+
+func chunkPayload(nonce string, index, count int, userIDs ...uint64) payload.SessionPayload {
+	chunk := receiveevents.GuildMembersChunk{
+		GuildID:    *structs.NewSnowflake(100),
+		ChunkIndex: index,
+		ChunkCount: count,
+		Nonce:      &nonce,
+	}
+	for _, id := range userIDs {
+		user := structs.User{ID: *structs.NewSnowflake(id)}
+		chunk.Members = append(chunk.Members, structs.GuildMember{User: &user})
+		chunk.Presences = append(chunk.Presences, receiveevents.PresenceUpdateEvent{PresenceUpdate: &structs.PresenceUpdate{User: user}})
+	}
+	return payload.SessionPayload{EventName: util.ToPtr("GUILD_MEMBERS_CHUNK"), Data: chunk}
+}
+
+func isDone(req *memberRequest) bool {
+	select {
+	case <-req.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestMemberRequestAggregatesChunks(t *testing.T) {
+	req := newTestMemberRequest("a")
+
+	req.offer(chunkPayload("a", 2, 3, 5))
+	req.offer(chunkPayload("b", 1, 3, 99))
+	req.offer(payload.SessionPayload{EventName: util.ToPtr("MESSAGE_CREATE"), Data: receiveevents.MessageCreateEvent{}})
+	req.offer(chunkPayload("a", 0, 3, 1, 2))
+	if isDone(req) {
+		t.Fatal("request done before every chunk arrived")
+	}
+
+	req.offer(chunkPayload("a", 1, 3, 3, 4))
+	if !isDone(req) {
+		t.Fatal("request not done after every chunk arrived")
+	}
+	// a duplicate chunk must not close done twice
+	req.offer(chunkPayload("a", 1, 3, 3, 4))
+
+	result := req.result()
+	var ids []uint64
+	for _, member := range result.Members {
+		ids = append(ids, member.User.ID.ID)
+	}
+	if want := []uint64{1, 2, 3, 4, 5}; len(ids) != len(want) {
+		t.Fatalf("members = %v, want %v", ids, want)
+	} else {
+		for i := range want {
+			if ids[i] != want[i] {
+				t.Fatalf("members = %v, want %v in chunk order", ids, want)
+			}
+		}
+	}
+	if len(result.Presences) != 5 {
+		t.Fatalf("got %d presences, want 5", len(result.Presences))
+	}
+}
+
+func TestMemberRequestNotFound(t *testing.T) {
+	req := newTestMemberRequest("a")
+	chunk := chunkPayload("a", 0, 1, 1)
+	data := chunk.Data.(receiveevents.GuildMembersChunk)
+	data.NotFound = []structs.Snowflake{*structs.NewSnowflake(2)}
+	chunk.Data = data
+
+	req.offer(chunk)
+	if !isDone(req) {
+		t.Fatal("request not done after its only chunk")
+	}
+	if result := req.result(); len(result.Members) != 1 || len(result.NotFound) != 1 || result.NotFound[0].ID != 2 {
+		t.Fatalf("result = %+v, want member 1 and user 2 not found", result)
+	}
+}
+
+func TestNewRequestGuildMembersEvent(t *testing.T) {
+	guildID := *structs.NewSnowflake(100)
+	tooMany := make([]structs.Snowflake, maxMemberRequestUserIDs+1)
+
+	tests := []struct {
+		name    string
+		intents []structs.Intent
+		request GuildMembersRequest
+		wantErr bool
+	}{
+		{name: "every member", intents: []structs.Intent{structs.GuildMembersIntent}},
+		{name: "every member without the intent", wantErr: true},
+		{name: "query without the intent", request: GuildMembersRequest{Query: "wum", Limit: 10}},
+		{name: "user IDs", request: GuildMembersRequest{UserIDs: []structs.Snowflake{*structs.NewSnowflake(1)}}},
+		{name: "too many user IDs", request: GuildMembersRequest{UserIDs: tooMany}, wantErr: true},
+		{name: "query and user IDs", request: GuildMembersRequest{Query: "wum", UserIDs: []structs.Snowflake{*structs.NewSnowflake(1)}}, wantErr: true},
+		{name: "negative limit", request: GuildMembersRequest{Query: "wum", Limit: -1}, wantErr: true},
+		{name: "presences without the intent", request: GuildMembersRequest{Query: "wum", Presences: true}, wantErr: true},
+		{
+			name:    "presences with the intent",
+			intents: []structs.Intent{structs.GuildPresencesIntent},
+			request: GuildMembersRequest{Query: "wum", Presences: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession()
+			s.intents = tt.intents
+			_, err := newRequestGuildMembersEvent(s, guildID, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRequestGuildMembersEvent() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestGuildMembersShard(t *testing.T) {
+	// the shard of a guild is (guild_id >> 22) % shards
+	onShardOne := *structs.NewSnowflake(1 << 22)
+	onShardZero := *structs.NewSnowflake(2 << 22)
+
+	s := newTestSession()
+	s.shard, s.shards = util.ToPtr(1), util.ToPtr(2)
+	s.intents = []structs.Intent{structs.GuildMembersIntent}
+
+	if _, err := newRequestGuildMembersEvent(s, onShardZero, GuildMembersRequest{}); err == nil {
+		t.Fatal("request for a guild of another shard was accepted")
+	}
+	event, err := newRequestGuildMembersEvent(s, onShardOne, GuildMembersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := requestGuildMembers(s, event); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.writes) != 1 {
+		t.Fatalf("got %d writes, want the request written once", len(s.writes))
+	}
+	var written struct {
+		OpCode gateway.GatewayOpCode `json:"op"`
+		Data   struct {
+			GuildID structs.Snowflake `json:"guild_id"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(s.writes[0], &written); err != nil {
+		t.Fatal(err)
+	}
+	if written.OpCode != gateway.GatewayOpRequestGuildMembers || !written.Data.GuildID.Equals(onShardOne) {
+		t.Fatalf("wrote %s, want a request for guild %s", s.writes[0], onShardOne.ToString())
+	}
+}
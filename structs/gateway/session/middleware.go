@@ -0,0 +1,76 @@
+package session
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+)
+
+// Middleware wraps a CommandFunc with cross-cutting behavior such as logging, tracing or access control.
+// A middleware receives the next CommandFunc in the chain and returns a new CommandFunc that should call it,
+// or return early to stop the event from reaching the handler.
+type Middleware func(next CommandFunc) CommandFunc
+
+// Use appends middlewares to the handler chain.
+// Middlewares run in the order they were registered, so the first registered middleware is the outermost one.
+func (e *eventHandler) Use(middlewares ...Middleware) {
+	e.mwMu.Lock()
+	defer e.mwMu.Unlock()
+	e.middlewares = append(e.middlewares, middlewares...)
+}
+
+// wrap applies the registered middlewares to the given handler.
+func (e *eventHandler) wrap(handler CommandFunc) CommandFunc {
+	e.mwMu.RLock()
+	defer e.mwMu.RUnlock()
+
+	for i := len(e.middlewares) - 1; i >= 0; i-- {
+		if e.middlewares[i] != nil {
+			handler = e.middlewares[i](handler)
+		}
+	}
+	return handler
+}
+
+// RecoveryMiddleware recovers from panics raised further down the chain and turns them into an error,
+// including the stack trace, so a single misbehaving handler can't take the process down with it.
+func RecoveryMiddleware() Middleware {
+	return func(next CommandFunc) CommandFunc {
+		return func(s ClientSession, p payload.SessionPayload) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic handling event %s: %v\n%s", eventName(p), r, debug.Stack())
+				}
+			}()
+			return next(s, p)
+		}
+	}
+}
+
+// SlowHandlerMiddleware logs a warning whenever the rest of the chain takes longer than the threshold to complete.
+func SlowHandlerMiddleware(threshold time.Duration) Middleware {
+	return func(next CommandFunc) CommandFunc {
+		return func(s ClientSession, p payload.SessionPayload) error {
+			start := time.Now()
+			err := next(s, p)
+			if elapsed := time.Since(start); elapsed > threshold {
+				log.Printf("slow handler for event %s: took %v (threshold %v)", eventName(p), elapsed, threshold)
+			}
+			return err
+		}
+	}
+}
+
+// eventName returns a readable name for the payload, falling back to the opcode name when there is no event name.
+func eventName(p payload.SessionPayload) string {
+	if p.EventName != nil {
+		return *p.EventName
+	}
+	if name, ok := opCodeNames[p.OpCode]; ok {
+		return name
+	}
+	return fmt.Sprintf("opcode %d", p.OpCode)
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	"github.com/Carmen-Shannon/simple-discord/util"
+)
+
+// recordingMiddleware appends its name to calls before and after the rest of the chain runs.
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next CommandFunc) CommandFunc {
+		return func(s ClientSession, p payload.SessionPayload) error {
+			*calls = append(*calls, name+" before")
+			err := next(s, p)
+			*calls = append(*calls, name+" after")
+			return err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	e := newEventHandler()
+	e.Use(recordingMiddleware("first", &calls), nil)
+	e.Use(recordingMiddleware("second", &calls))
+
+	handler := e.wrap(func(ClientSession, payload.SessionPayload) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	if err := handler(nil, payload.SessionPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first before", "second before", "handler", "second after", "first after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	errDenied := errors.New("denied")
+	e := newEventHandler()
+	e.Use(func(next CommandFunc) CommandFunc {
+		return func(ClientSession, payload.SessionPayload) error {
+			return errDenied
+		}
+	})
+
+	called := false
+	handler := e.wrap(func(ClientSession, payload.SessionPayload) error {
+		called = true
+		return nil
+	})
+
+	if err := handler(nil, payload.SessionPayload{}); !errors.Is(err, errDenied) {
+		t.Fatalf("err = %v, want %v", err, errDenied)
+	}
+	if called {
+		t.Fatal("handler ran after the middleware returned early")
+	}
+}
+
+func TestMiddlewareWithoutMiddlewares(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	handler := newEventHandler().wrap(func(ClientSession, payload.SessionPayload) error {
+		return errHandler
+	})
+	if err := handler(nil, payload.SessionPayload{}); !errors.Is(err, errHandler) {
+		t.Fatalf("err = %v, want %v", err, errHandler)
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	handler := RecoveryMiddleware()(func(ClientSession, payload.SessionPayload) error {
+		panic("boom")
+	})
+
+	err := handler(nil, payload.SessionPayload{EventName: util.ToPtr("MESSAGE_CREATE")})
+	if err == nil {
+		t.Fatal("RecoveryMiddleware() didn't turn the panic into an error")
+	}
+	if !strings.HasPrefix(err.Error(), "panic handling event MESSAGE_CREATE: boom") {
+		t.Fatalf("err = %q, want the event name and the panic value", err)
+	}
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// Modal registers a handler for modal submissions whose custom ID matches the pattern, see `Component` for the pattern syntax.
+//
+// Example:
+//
+//	router.Modal("ticket:{category}", func(ctx *session.CommandContext) error {
+//	    values := ctx.ModalValues()
+//	    return ctx.ReplyEphemeral("ticket opened in " + ctx.Param("category") + ": " + values["subject"])
+//	})
+func (r *CommandRouter) Modal(pattern string, handler CommandHandler) error {
+	p, err := newCustomIDPattern(pattern, handler)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modals = append(r.modals, p)
+	return nil
+}
+
+// LookupModal returns the handler of the first modal pattern matching the custom ID, along with the captured parts.
+func (r *CommandRouter) LookupModal(customID string) (CommandHandler, map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return lookupPattern(r.modals, customID)
+}
+
+// HandleModal registers a handler for modal submissions that receives the submitted text inputs bound into T.
+// T must be a struct, see `CommandContext.BindModal` for the supported fields and tags.
+// When a value fails validation the user gets an ephemeral reply explaining what was wrong, and the handler is not called.
+//
+// Example:
+//
+//	type ticketForm struct {
+//	    Subject     string `discord:"subject,required"`
+//	    Description string `discord:"description"`
+//	    OrderNumber *int   `discord:"order"`
+//	}
+//
+//	session.HandleModal(router, "ticket:{category}", func(ctx *session.CommandContext, form ticketForm) error {
+//	    return ctx.ReplyEphemeral("thanks, we'll look into " + form.Subject)
+//	})
+func HandleModal[T any](r *CommandRouter, pattern string, handler func(ctx *CommandContext, form T) error) error {
+	return r.Modal(pattern, func(ctx *CommandContext) error {
+		var form T
+		if err := ctx.BindModal(&form); err != nil {
+			var fieldErr *FieldError
+			if errors.As(err, &fieldErr) {
+				return ctx.ReplyEphemeral("Sorry, I couldn't process that form: " + fieldErr.Error() + ".")
+			}
+			return err
+		}
+		return handler(ctx, form)
+	})
+}
+
+// ShowModal responds to the interaction with a modal, see `structs.NewModal`.
+// Modals can't be shown in response to a modal submission, or after the interaction was deferred.
+func (c *CommandContext) ShowModal(modal structs.InteractionResponseOptions) error {
+	if modal.InteractionResponse().Type != structs.ModalInteraction {
+		return errors.New("response is not a modal")
+	}
+	return c.respond(modal)
+}
+
+// ModalValues returns the values of the submitted text inputs, keyed by their custom ID.
+func (c *CommandContext) ModalValues() map[string]string {
+	values := make(map[string]string)
+	if c.Interaction == nil || c.Interaction.Data == nil {
+		return values
+	}
+
+	var collect func(components []structs.MessageComponent)
+	collect = func(components []structs.MessageComponent) {
+		for _, component := range components {
+			if component.Type == structs.TextInputMessageComponent {
+				values[component.CustomID] = component.Value
+			}
+			collect(component.Components)
+		}
+	}
+	collect(c.Interaction.Data.Components)
+	return values
+}
+
+// FieldError is returned by `CommandContext.BindModal` when a text input is missing or has an invalid value.
+// The message is meant to be shown to the user that submitted the modal.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("the `%s` field %s", e.Field, e.Reason)
+}
+
+// BindModal copies the submitted text inputs into the struct pointed to by dst, using the `discord` tag of each field.
+// The tag holds the custom ID of the text input, optionally followed by `required`, i.e `discord:"subject,required"`.
+// Empty inputs are treated as missing. Strings get the text exactly as submitted, while numbers, booleans and snowflakes
+// are parsed from the text without its surrounding whitespace. Any of these can be a pointer, which is left nil when
+// the input was empty.
+//
+// A missing required input or an invalid value returns a *FieldError.
+func (c *CommandContext) BindModal(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a pointer to a struct")
+	}
+	v = v.Elem()
+
+	values := c.ModalValues()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("discord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		customID, flags, _ := strings.Cut(tag, ",")
+		value := values[customID]
+		base := field.Type
+		if base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		if base.Kind() != reflect.String {
+			value = strings.TrimSpace(value)
+		}
+		if value == "" {
+			if flags == "required" {
+				return &FieldError{Field: customID, Reason: "is required"}
+			}
+			continue
+		}
+
+		// text inputs are converted the same way as command options, booleans are the only value that isn't a string
+		var raw any = value
+		if base.Kind() == reflect.Bool {
+			switch strings.ToLower(value) {
+			case "true", "yes", "y", "1":
+				raw = true
+			case "false", "no", "n", "0":
+				raw = false
+			}
+		}
+
+		converted, err := optionValue(structs.ApplicationCommandInteractionDataOption{Name: customID, Value: raw}, nil, field.Type)
+		if err != nil {
+			var optionErr *OptionError
+			if errors.As(err, &optionErr) {
+				return &FieldError{Field: customID, Reason: optionErr.Reason}
+			}
+			return err
+		}
+		v.Field(i).Set(converted)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// modalSubmit returns a modal submission with a text input for every value, each in its own action row.
+func modalSubmit(values map[string]string) map[string]any {
+	var rows []map[string]any
+	for customID, value := range values {
+		rows = append(rows, map[string]any{
+			"type": structs.ActionRowMessageComponent,
+			"components": []map[string]any{{
+				"type":      structs.TextInputMessageComponent,
+				"custom_id": customID,
+				"value":     value,
+			}},
+		})
+	}
+	return map[string]any{
+		"type": structs.ModalSubmitInteraction,
+		"data": map[string]any{"custom_id": "ticket", "components": rows},
+	}
+}
+
+func TestBindModal(t *testing.T) {
+	type form struct {
+		Subject  string             `discord:"subject,required"`
+		Details  string             `discord:"details"`
+		Priority int                `discord:"priority"`
+		Urgent   bool               `discord:"urgent"`
+		OrderID  *structs.Snowflake `discord:"order"`
+		Budget   *float64           `discord:"budget"`
+	}
+
+	ctx := commandContext(t, modalSubmit(map[string]string{
+		"subject":  "Refund",
+		"details":  "  line one\nline two ",
+		"priority": " 2 ",
+		"urgent":   "Yes",
+		"order":    "1234",
+		"budget":   "",
+	}))
+
+	var got form
+	if err := ctx.BindModal(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Subject != "Refund" || got.Details != "  line one\nline two " {
+		t.Fatalf("text = %q and %q, want the text exactly as submitted", got.Subject, got.Details)
+	}
+	if got.Priority != 2 || !got.Urgent {
+		t.Fatalf("priority = %d and urgent = %v, want 2 and true", got.Priority, got.Urgent)
+	}
+	if got.OrderID == nil || got.OrderID.ID != 1234 {
+		t.Fatalf("order = %v, want 1234", got.OrderID)
+	}
+	if got.Budget != nil {
+		t.Fatalf("budget = %v, want nil for an empty input", *got.Budget)
+	}
+}
+
+func TestBindModalErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		dst       any
+		values    map[string]string
+		wantField string
+	}{
+		{
+			name: "missing required input",
+			dst: &struct {
+				Subject string `discord:"subject,required"`
+			}{},
+			wantField: "subject",
+		},
+		{
+			name: "blank required number",
+			dst: &struct {
+				Amount int `discord:"amount,required"`
+			}{},
+			values:    map[string]string{"amount": "   "},
+			wantField: "amount",
+		},
+		{
+			name: "text for a number",
+			dst: &struct {
+				Amount int `discord:"amount"`
+			}{},
+			values:    map[string]string{"amount": "ten"},
+			wantField: "amount",
+		},
+		{
+			name: "unknown answer for a boolean",
+			dst: &struct {
+				Urgent bool `discord:"urgent"`
+			}{},
+			values:    map[string]string{"urgent": "maybe"},
+			wantField: "urgent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := commandContext(t, modalSubmit(tt.values))
+
+			var fieldErr *FieldError
+			if err := ctx.BindModal(tt.dst); !errors.As(err, &fieldErr) {
+				t.Fatalf("BindModal() = %v, want a *FieldError", err)
+			}
+			if fieldErr.Field != tt.wantField {
+				t.Fatalf("field = %s, want %s", fieldErr.Field, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util"
+	requestutil "github.com/Carmen-Shannon/simple-discord/util/request_util"
+)
+
+var ErrNoPages = errors.New("paginator has no pages")
+
+const (
+	defaultPaginatorTimeout = 2 * time.Minute
+	// interaction tokens expire after 15 minutes, the buttons have to be disabled before that
+	maxPaginatorLifetime = 14 * time.Minute
+)
+
+// PageLoader loads a single page of a lazy paginator, pages are zero-indexed and loaded at most once.
+type PageLoader func(page int) (structs.Embed, error)
+
+// PaginatorOptions configures a Paginator.
+type PaginatorOptions struct {
+	// Timeout is how long the buttons stay active after the last click, defaults to 2 minutes.
+	// Paginators stop after 14 minutes regardless, since the interaction token can't be used after 15 minutes.
+	Timeout time.Duration
+	// Ephemeral makes the paginator visible only to the user that invoked the command.
+	Ephemeral bool
+	// AllowEveryone lets anyone flip through the pages, by default only the user that invoked the command can.
+	AllowEveryone bool
+}
+
+// Paginator shows one embed at a time with buttons to flip through the pages.
+type Paginator struct {
+	mu      *sync.Mutex
+	pages   map[int]structs.Embed
+	loader  PageLoader
+	total   int
+	current int
+	opts    PaginatorOptions
+}
+
+// NewPaginator creates a paginator over a fixed set of pages.
+func NewPaginator(pages []structs.Embed, opts PaginatorOptions) *Paginator {
+	p := newPaginator(len(pages), nil, opts)
+	for i, page := range pages {
+		p.pages[i] = page
+	}
+	return p
+}
+
+// NewLazyPaginator creates a paginator with the given amount of pages, each page is loaded the first time it is shown.
+//
+// Example:
+//
+//	paginator := session.NewLazyPaginator(totalPages, func(page int) (structs.Embed, error) {
+//	    rows, err := db.Leaderboard(page*10, 10)
+//	    if err != nil {
+//	        return structs.Embed{}, err
+//	    }
+//	    return leaderboardEmbed(rows), nil
+//	}, session.PaginatorOptions{Ephemeral: true})
+//	return paginator.Start(ctx)
+func NewLazyPaginator(total int, loader PageLoader, opts PaginatorOptions) *Paginator {
+	return newPaginator(total, loader, opts)
+}
+
+func newPaginator(total int, loader PageLoader, opts PaginatorOptions) *Paginator {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultPaginatorTimeout
+	}
+	return &Paginator{
+		mu:     &sync.Mutex{},
+		pages:  make(map[int]structs.Embed),
+		loader: loader,
+		total:  total,
+		opts:   opts,
+	}
+}
+
+// Start responds to the interaction with the first page and handles the button clicks in the background,
+// until the paginator times out and the buttons are disabled.
+func (p *Paginator) Start(ctx *CommandContext) error {
+	if p.total <= 0 {
+		return ErrNoPages
+	}
+	if ctx.Session == nil || ctx.Interaction == nil {
+		return errors.New("paginator needs an interaction received on a session")
+	}
+
+	embed, err := p.page(0)
+	if err != nil {
+		return err
+	}
+
+	id := "paginator:" + ctx.Interaction.ID.ToString()
+	response := structs.NewInteractionResponseOptions()
+	response.SetResponseType(structs.ChannelMessageWithSourceInteraction)
+	if err := response.SetEmbeds([]structs.Embed{embed}); err != nil {
+		return err
+	}
+	response.SetComponents(p.components(id, 0, false))
+	if p.opts.Ephemeral {
+		if err := response.SetFlags(structs.NewBitfield(structs.EphemeralMessageFlag)); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Respond(response); err != nil {
+		return err
+	}
+
+	collector := NewCollector(context.Background(), ctx.Session, func(ev receiveevents.InteractionCreateEvent) bool {
+		return ev.Interaction != nil && ev.Type == structs.MessageComponentInteraction &&
+			ev.Data != nil && strings.HasPrefix(ev.Data.CustomID, id+":")
+	}, CollectorOptions{IdleTimeout: p.opts.Timeout, Timeout: maxPaginatorLifetime})
+
+	go p.run(ctx, collector, id)
+	return nil
+}
+
+// Current returns the zero-indexed page currently shown.
+func (p *Paginator) Current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current
+}
+
+func (p *Paginator) run(ctx *CommandContext, collector *Collector[receiveevents.InteractionCreateEvent], id string) {
+	author := ctx.Author()
+
+	for ev := range collector.Events() {
+		click := NewInteractionContext(ctx.Session, payload.SessionPayload{EventName: util.ToPtr("INTERACTION_CREATE"), Data: ev}, ev.Interaction)
+
+		if !p.opts.AllowEveryone && author != nil {
+			if clicker := click.Author(); clicker == nil || !clicker.ID.Equals(author.ID) {
+				if err := click.ReplyEphemeral("Only the person who used this command can change pages."); err != nil {
+					log.Printf("paginator %s: %v", id, err)
+				}
+				continue
+			}
+		}
+
+		if err := p.flip(click, id, strings.TrimPrefix(ev.Data.CustomID, id+":")); err != nil {
+			log.Printf("paginator %s: %v", id, err)
+		}
+	}
+
+	// disable the buttons through the original response, which works for ephemeral messages too
+	data := structs.InteractionResponseData{Components: p.components(id, p.Current(), true)}
+	if _, err := requestutil.EditOriginalInteractionResponse(ctx.Interaction.ApplicationID.ToString(), ctx.Interaction.Token, data); err != nil {
+		log.Printf("paginator %s: failed to disable buttons: %v", id, err)
+	}
+}
+
+func (p *Paginator) flip(click *CommandContext, id, action string) error {
+	p.mu.Lock()
+	page := p.current
+	switch action {
+	case "first":
+		page = 0
+	case "prev":
+		page--
+	case "next":
+		page++
+	case "last":
+		page = p.total - 1
+	}
+	page = max(0, min(page, p.total-1))
+	_, loaded := p.pages[page]
+	p.mu.Unlock()
+
+	// a page that still has to be loaded could take longer than the click can wait to be acknowledged,
+	// so the click is deferred and the message edited once the page is there
+	if !loaded {
+		if err := click.DeferUpdate(); err != nil {
+			return err
+		}
+	}
+
+	embed, err := p.page(page)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.current = page
+	p.mu.Unlock()
+
+	if !loaded {
+		data := structs.InteractionResponseData{Embeds: []structs.Embed{embed}, Components: p.components(id, page, false)}
+		_, err := requestutil.EditOriginalInteractionResponse(click.Interaction.ApplicationID.ToString(), click.Interaction.Token, data)
+		return err
+	}
+
+	response := structs.NewInteractionResponseOptions()
+	if err := response.SetEmbeds([]structs.Embed{embed}); err != nil {
+		return err
+	}
+	response.SetComponents(p.components(id, page, false))
+	return click.Update(response)
+}
+
+// page returns the embed of the page, loading it first if needed. The lock isn't held while the loader runs,
+// so reading the current page doesn't wait on it.
+func (p *Paginator) page(page int) (structs.Embed, error) {
+	p.mu.Lock()
+	embed, ok := p.pages[page]
+	p.mu.Unlock()
+
+	if ok {
+		return embed, nil
+	}
+	if p.loader == nil {
+		return structs.Embed{}, fmt.Errorf("page %d does not exist", page)
+	}
+
+	embed, err := p.loader(page)
+	if err != nil {
+		return structs.Embed{}, fmt.Errorf("failed to load page %d: %w", page, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if loaded, ok := p.pages[page]; ok {
+		return loaded, nil
+	}
+	p.pages[page] = embed
+	return embed, nil
+}
+
+func (p *Paginator) components(id string, page int, disabled bool) []structs.MessageComponent {
+	button := func(label, action string, off bool) structs.MessageComponent {
+		b := structs.NewButton(structs.SecondaryButtonStyle, label, id+":"+action)
+		b.Disabled = disabled || off
+		return b
+	}
+
+	first := page == 0
+	last := page == p.total-1
+	return []structs.MessageComponent{
+		structs.NewActionRow(
+			button("⏮", "first", first),
+			button("◀", "prev", first),
+			button(fmt.Sprintf("%d/%d", page+1, p.total), "page", true),
+			button("▶", "next", last),
+			button("⏭", "last", last),
+		),
+	}
+}
@@ -0,0 +1,181 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	sendevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/send_events"
+)
+
+// presenceInterval is the least time between two presence updates sent by a session,
+// updates made in between are coalesced so only the latest one is sent.
+const presenceInterval = 5 * time.Second
+
+// PresenceSetter is implemented by `ClientSession`, and by the bot to update every shard at once.
+type PresenceSetter interface {
+	SetPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error
+}
+
+// SetPresence updates the status and activities of the bot on this shard.
+// Updates closer than 5 seconds apart are coalesced, the latest one is sent once the interval is over.
+// The presence is kept for the next identify, so it survives a reconnect.
+//
+// Parameters:
+//   - status: The status of the bot, an empty status is online.
+//   - activities: The activities to show, only the name, type, url and state are sent.
+//   - afk: Whether the bot is away from keyboard.
+//
+// Returns:
+//   - error: An error if the status isn't one Discord accepts.
+//
+// Example:
+//
+//	err := sess.SetPresence(structs.UserDND, []structs.Activity{{
+//	    Name: "the logs",
+//	    Type: structs.WatchingActivity,
+//	}}, false)
+func (s *clientSession) SetPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error {
+	presence, err := newPresence(status, activities, afk)
+	if err != nil {
+		return err
+	}
+
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+	s.presence = &presence
+	if s.presencePending {
+		return nil
+	}
+
+	if wait := presenceInterval - time.Since(s.presenceSentAt); wait > 0 {
+		s.presencePending = true
+		time.AfterFunc(wait, s.flushPresence)
+		return nil
+	}
+	s.presenceSentAt = time.Now()
+	return s.sendPresence(presence)
+}
+
+// SetInitialPresence sets the presence sent when the session identifies, without sending an update.
+// Call it before `Dial` so the bot comes online with the presence, see `SetPresence` for the parameters.
+func (s *clientSession) SetInitialPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) error {
+	presence, err := newPresence(status, activities, afk)
+	if err != nil {
+		return err
+	}
+
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+	s.presence = &presence
+	return nil
+}
+
+// GetPresence returns a copy of the latest presence set on the session, nil if none was set.
+func (s *clientSession) GetPresence() *sendevents.PresenceUpdateEvent {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+	if s.presence == nil {
+		return nil
+	}
+	presence := *s.presence
+	return &presence
+}
+
+// copyPresence carries the presence over to the session replacing this one.
+func (s *clientSession) copyPresence(sess ClientSession) {
+	if presence := s.GetPresence(); presence != nil {
+		sess.SetInitialPresence(presence.Status, presence.Activities, presence.Afk)
+	}
+}
+
+// flushPresence sends the latest presence once the interval of a coalesced update is over.
+func (s *clientSession) flushPresence() {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+	s.presencePending = false
+	if s.presence == nil || s.ctx.Err() != nil {
+		return
+	}
+
+	s.presenceSentAt = time.Now()
+	if err := s.sendPresence(*s.presence); err != nil {
+		log.Printf("failed to update the presence: %v", err)
+	}
+}
+
+func (s *clientSession) sendPresence(presence sendevents.PresenceUpdateEvent) error {
+	presencePayload := payload.SessionPayload{
+		OpCode: gateway.GatewayOpPresenceUpdate,
+		Data:   presence,
+	}
+	return s.eventHandler.HandleEvent(s, presencePayload)
+}
+
+func newPresence(status structs.UserStatusType, activities []structs.Activity, afk bool) (sendevents.PresenceUpdateEvent, error) {
+	switch status {
+	case "":
+		status = structs.UserOnline
+	case structs.UserOnline, structs.UserDND, structs.UserIdle, structs.UserInvisible, structs.UserOffline:
+	default:
+		return sendevents.PresenceUpdateEvent{}, errors.New("invalid status: " + string(status))
+	}
+
+	presence := sendevents.PresenceUpdateEvent{
+		Activities: activities,
+		Status:     status,
+		Afk:        afk,
+	}
+	if afk {
+		presence.Since = int(time.Now().UnixMilli())
+	}
+	return presence, nil
+}
+
+// RotatePresence cycles through the activities on an interval, showing one at a time with the status,
+// until the context is done. It returns right away, the rotation runs in the background.
+//
+// Parameters:
+//   - ctx: Stops the rotation when done.
+//   - setter: The session, or the bot to rotate the presence on every shard.
+//   - interval: How long each activity is shown, it can't be shorter than 5 seconds.
+//   - status: The status shown alongside the activities.
+//   - activities: The activities to cycle through, in order.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	session.RotatePresence(ctx, bot, time.Minute, structs.UserOnline,
+//	    structs.Activity{Name: "/help", Type: structs.ListeningActivity},
+//	    structs.Activity{Name: "over the server", Type: structs.WatchingActivity},
+//	)
+func RotatePresence(ctx context.Context, setter PresenceSetter, interval time.Duration, status structs.UserStatusType, activities ...structs.Activity) {
+	if len(activities) == 0 {
+		return
+	}
+	if interval < presenceInterval {
+		interval = presenceInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for i := 0; ; i = (i + 1) % len(activities) {
+			if err := setter.SetPresence(status, []structs.Activity{activities[i]}, false); err != nil {
+				log.Printf("failed to rotate the presence: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
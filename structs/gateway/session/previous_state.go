@@ -0,0 +1,66 @@
+package session
+
+import (
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+)
+
+// withPreviousState fills the `Old` field of the update and delete events with the cached entity they replace,
+// so the listeners get both values. It runs on the gateway reader when the event is received, before the event is
+// queued, so the value doesn't depend on which worker handles the event or when.
+// `Old` stays nil when the entity wasn't cached, or when the cache store failed to read it.
+func withPreviousState(s ClientSession, p payload.SessionPayload) payload.SessionPayload {
+	c := s.GetCache()
+	if c == nil {
+		return p
+	}
+
+	switch event := p.Data.(type) {
+	case receiveevents.ChannelUpdateEvent:
+		if event.Channel != nil {
+			event.Old, _ = c.GetChannel(event.ID)
+		}
+		p.Data = event
+	case receiveevents.ChannelDeleteEvent:
+		if event.Channel != nil {
+			event.Old, _ = c.GetChannel(event.ID)
+		}
+		p.Data = event
+	case receiveevents.ThreadUpdateEvent:
+		if event.Channel != nil {
+			event.Old, _ = c.GetChannel(event.ID)
+		}
+		p.Data = event
+	case receiveevents.ThreadDeleteEvent:
+		event.Old, _ = c.GetChannel(event.ID)
+		p.Data = event
+	case receiveevents.GuildMemberUpdateEvent:
+		event.Old, _ = c.GetMember(event.GuildID, event.User.ID)
+		p.Data = event
+	case receiveevents.GuildMemberRemoveEvent:
+		event.Old, _ = c.GetMember(event.GuildID, event.User.ID)
+		p.Data = event
+	case receiveevents.GuildRoleUpdateEvent:
+		event.Old, _ = c.GetRole(event.GuildID, event.Role.ID)
+		p.Data = event
+	case receiveevents.GuildRoleDeleteEvent:
+		event.Old, _ = c.GetRole(event.GuildID, event.RoleID)
+		p.Data = event
+	case receiveevents.MessageUpdateEvent:
+		if event.Message != nil {
+			event.Old, _ = c.GetMessage(event.ChannelID, event.ID)
+		}
+		p.Data = event
+	case receiveevents.MessageDeleteEvent:
+		event.Old, _ = c.GetMessage(event.ChannelID, event.ID)
+		p.Data = event
+	case receiveevents.MessageDeleteBulkEvent:
+		for _, id := range event.IDs {
+			if message, err := c.GetMessage(event.ChannelID, id); err == nil && message != nil {
+				event.Old = append(event.Old, *message)
+			}
+		}
+		p.Data = event
+	}
+	return p
+}
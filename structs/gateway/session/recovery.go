@@ -0,0 +1,118 @@
+package session
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+)
+
+// ErrorContext describes an error returned by a handler, or a panic recovered from one.
+type ErrorContext struct {
+	// Shard is the shard ID of the session that handled the event, -1 for interactions received by an InteractionServer.
+	Shard int
+	// EventName is the name of the gateway event, or the opcode name for events without one.
+	EventName string
+	// GuildID is the guild the event came from, nil for events outside of a guild.
+	GuildID *structs.Snowflake
+	// Interaction is the interaction being handled, nil for anything other than INTERACTION_CREATE.
+	Interaction *structs.Interaction
+	// Payload is the raw payload handed to the handler.
+	Payload payload.SessionPayload
+	// Err is the error returned by the handler, or an error describing the panic.
+	Err error
+	// Panic is the value recovered from the panic, nil if the handler returned an error instead.
+	Panic any
+	// Stack is the stack trace captured when the panic was recovered, nil if the handler returned an error instead.
+	Stack []byte
+}
+
+// OnError sets the callback receiving every error returned by, and every panic recovered from, a handler.
+// Errors are still logged by the session, the callback is only an addition to that.
+func (e *eventHandler) OnError(handler func(ErrorContext)) {
+	e.errMu.Lock()
+	defer e.errMu.Unlock()
+	e.onError = handler
+}
+
+func (e *eventHandler) getOnError() func(ErrorContext) {
+	e.errMu.RLock()
+	defer e.errMu.RUnlock()
+	return e.onError
+}
+
+// call runs the handler, recovering from any panic, and reports anything that went wrong to the session and the error callback.
+func (e *eventHandler) call(s ClientSession, p payload.SessionPayload, handler CommandFunc) {
+	var (
+		err       error
+		recovered any
+		stack     []byte
+	)
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				recovered = r
+				stack = debug.Stack()
+				err = fmt.Errorf("panic handling event %s: %v", eventName(p), r)
+			}
+		}()
+		err = handler(s, p)
+	}()
+
+	if err == nil {
+		return
+	}
+
+	if recovered != nil {
+		s.Error(fmt.Errorf("%w\n%s", err, stack))
+	} else {
+		s.Error(err)
+	}
+
+	onError := e.getOnError()
+	if onError == nil {
+		return
+	}
+
+	ctx := ErrorContext{
+		EventName: eventName(p),
+		GuildID:   eventGuildID(p),
+		Payload:   p,
+		Err:       err,
+		Panic:     recovered,
+		Stack:     stack,
+	}
+	if shard := s.GetShard(); shard != nil {
+		ctx.Shard = *shard
+	}
+	if ev, ok := p.Data.(receiveevents.InteractionCreateEvent); ok {
+		ctx.Interaction = ev.Interaction
+	}
+
+	// the callback is user code as well, so it gets the same treatment
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in error callback: %v\n%s", r, debug.Stack())
+		}
+	}()
+	onError(ctx)
+}
+
+// eventGuildID finds the guild an event belongs to, nil for events outside of a guild.
+func eventGuildID(p payload.SessionPayload) *structs.Snowflake {
+	if p.Data == nil {
+		return nil
+	}
+	if id := snowflakeField(p.Data, "GuildID"); id != nil {
+		return id
+	}
+	if p.EventName != nil && strings.HasPrefix(*p.EventName, "GUILD_") {
+		return snowflakeField(p.Data, "ID")
+	}
+	return nil
+}
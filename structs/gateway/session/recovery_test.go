@@ -0,0 +1,177 @@
+package session
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util"
+)
+
+// testSession implements the parts of ClientSession the tests need, calling anything else panics.
+type testSession struct {
+	ClientSession
+
+	mu      *sync.Mutex
+	shard   *int
+	shards  *int
+	intents []structs.Intent
+	cache   cache.CacheStore
+	writes  [][]byte
+	errs    []error
+}
+
+func newTestSession() *testSession {
+	return &testSession{mu: &sync.Mutex{}}
+}
+
+func (s *testSession) Write(data []byte, binary bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, data)
+}
+
+func (s *testSession) Error(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *testSession) GetShard() *int               { return s.shard }
+func (s *testSession) GetShards() *int              { return s.shards }
+func (s *testSession) GetIntents() []structs.Intent { return s.intents }
+func (s *testSession) GetCache() cache.CacheStore   { return s.cache }
+
+func TestCallReportsErrors(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	interaction := &structs.Interaction{ID: *structs.NewSnowflake(5)}
+
+	tests := []struct {
+		name    string
+		p       payload.SessionPayload
+		handler CommandFunc
+		check   func(t *testing.T, ctx ErrorContext)
+	}{
+		{
+			name: "returned error",
+			p:    messagePayload(1, 2),
+			handler: func(ClientSession, payload.SessionPayload) error {
+				return errHandler
+			},
+			check: func(t *testing.T, ctx ErrorContext) {
+				if !errors.Is(ctx.Err, errHandler) || ctx.Panic != nil || ctx.Stack != nil {
+					t.Fatalf("error context = %+v, want the returned error without a panic", ctx)
+				}
+			},
+		},
+		{
+			name: "recovered panic",
+			p:    messagePayload(1, 2),
+			handler: func(ClientSession, payload.SessionPayload) error {
+				panic("boom")
+			},
+			check: func(t *testing.T, ctx ErrorContext) {
+				if ctx.Panic != "boom" || len(ctx.Stack) == 0 {
+					t.Fatalf("panic = %v with a %d byte stack, want boom and a stack", ctx.Panic, len(ctx.Stack))
+				}
+				if ctx.Err == nil || ctx.Err.Error() != "panic handling event MESSAGE_CREATE: boom" {
+					t.Fatalf("err = %v, want the panic described", ctx.Err)
+				}
+			},
+		},
+		{
+			name: "interaction",
+			p: payload.SessionPayload{
+				EventName: util.ToPtr("INTERACTION_CREATE"),
+				Data:      receiveevents.InteractionCreateEvent{Interaction: interaction},
+			},
+			handler: func(ClientSession, payload.SessionPayload) error {
+				return errHandler
+			},
+			check: func(t *testing.T, ctx ErrorContext) {
+				if ctx.Interaction != interaction {
+					t.Fatalf("interaction = %v, want %v", ctx.Interaction, interaction)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession()
+			s.shard = util.ToPtr(3)
+			e := newEventHandler()
+
+			var reported []ErrorContext
+			e.OnError(func(ctx ErrorContext) {
+				reported = append(reported, ctx)
+			})
+			e.call(s, tt.p, tt.handler)
+
+			if len(reported) != 1 {
+				t.Fatalf("reported %d errors, want 1", len(reported))
+			}
+			if len(s.errs) != 1 {
+				t.Fatalf("session got %d errors, want 1", len(s.errs))
+			}
+			ctx := reported[0]
+			if ctx.Shard != 3 || ctx.EventName != eventName(tt.p) {
+				t.Fatalf("error context = %+v, want shard 3 and event %s", ctx, eventName(tt.p))
+			}
+			tt.check(t, ctx)
+		})
+	}
+}
+
+func TestCallGuildID(t *testing.T) {
+	s := newTestSession()
+	e := newEventHandler()
+
+	var guildID *structs.Snowflake
+	e.OnError(func(ctx ErrorContext) {
+		guildID = ctx.GuildID
+	})
+	e.call(s, messagePayload(9, 2), func(ClientSession, payload.SessionPayload) error {
+		return errors.New("failed")
+	})
+
+	if guildID == nil || guildID.ID != 9 {
+		t.Fatalf("guild ID = %v, want 9", guildID)
+	}
+}
+
+func TestCallWithoutError(t *testing.T) {
+	s := newTestSession()
+	e := newEventHandler()
+	e.OnError(func(ctx ErrorContext) {
+		t.Fatalf("OnError called with %v", ctx.Err)
+	})
+
+	e.call(s, messagePayload(1, 2), func(ClientSession, payload.SessionPayload) error {
+		return nil
+	})
+	if len(s.errs) != 0 {
+		t.Fatalf("session got errors %v, want none", s.errs)
+	}
+}
+
+func TestCallRecoversFromErrorCallback(t *testing.T) {
+	s := newTestSession()
+	e := newEventHandler()
+	e.OnError(func(ErrorContext) {
+		panic("callback failed")
+	})
+
+	// the panic of the callback must not reach the caller
+	e.call(s, messagePayload(1, 2), func(ClientSession, payload.SessionPayload) error {
+		return errors.New("failed")
+	})
+	if len(s.errs) != 1 || !strings.Contains(s.errs[0].Error(), "failed") {
+		t.Fatalf("session errors = %v, want the handler error", s.errs)
+	}
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
+)
+
+const (
+	// gatewaySendLimit is the most events Discord accepts from a connection within gatewaySendWindow, going over closes it.
+	gatewaySendLimit  = 120
+	gatewaySendWindow = 60 * time.Second
+	// gatewaySendReserve is the part of the limit only heartbeats, identify and resume can use,
+	// so a burst of other events can't delay them past the heartbeat interval.
+	gatewaySendReserve = 5
+)
+
+// sendLimiter is a token bucket for the events sent on a gateway connection, each token comes back
+// gatewaySendWindow after it was spent. Unlike a bucket refilling at a steady rate, this never lets more than
+// gatewaySendLimit events through in any window, however Discord aligns its own.
+type sendLimiter struct {
+	mu   *sync.Mutex
+	sent []time.Time
+
+	// queueMu keeps the regular sends waiting in line, in the order they were written.
+	queueMu *sync.Mutex
+}
+
+func newSendLimiter() *sendLimiter {
+	return &sendLimiter{
+		mu:      &sync.Mutex{},
+		sent:    make([]time.Time, 0, gatewaySendLimit),
+		queueMu: &sync.Mutex{},
+	}
+}
+
+// wait blocks until a token can be spent on the event, and reports false if the context is done first.
+// Priority events can spend the reserved tokens and skip the queue of the regular ones.
+func (l *sendLimiter) wait(ctx context.Context, priority bool) bool {
+	limit := gatewaySendLimit
+	if !priority {
+		limit -= gatewaySendReserve
+		l.queueMu.Lock()
+		defer l.queueMu.Unlock()
+	}
+
+	for {
+		delay := l.take(limit)
+		if delay == 0 {
+			return true
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// take spends a token if less than limit were spent within the window, otherwise it returns how long until one comes back.
+func (l *sendLimiter) take(limit int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	expired := 0
+	for expired < len(l.sent) && now.Sub(l.sent[expired]) >= gatewaySendWindow {
+		expired++
+	}
+	l.sent = append(l.sent[:0], l.sent[expired:]...)
+
+	if len(l.sent) < limit {
+		l.sent = append(l.sent, now)
+		return 0
+	}
+	return l.sent[len(l.sent)-limit].Add(gatewaySendWindow).Sub(now)
+}
+
+// Write sends the event once the send rate limit of the gateway allows it, blocking the caller until then.
+// Heartbeats, identify and resume use a reserved part of the limit and go ahead of the other events waiting.
+func (s *clientSession) Write(data []byte, binary bool) {
+	if !s.limiter.wait(s.ctx, isPriorityEvent(data)) {
+		return
+	}
+	s.Session.Write(data, binary)
+}
+
+// isPriorityEvent reports whether the event keeps the connection alive, and shouldn't wait behind the others.
+func isPriorityEvent(data []byte) bool {
+	var event struct {
+		OpCode gateway.GatewayOpCode `json:"op"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return false
+	}
+
+	switch event.OpCode {
+	case gateway.GatewayOpHeartbeat, gateway.GatewayOpIdentify, gateway.GatewayOpResume:
+		return true
+	default:
+		return false
+	}
+}
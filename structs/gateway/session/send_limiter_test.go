@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendLimiterReserve(t *testing.T) {
+	l := newSendLimiter()
+	regular := gatewaySendLimit - gatewaySendReserve
+
+	for i := 0; i < regular; i++ {
+		if delay := l.take(regular); delay != 0 {
+			t.Fatalf("event %d waited %v, want it sent right away", i, delay)
+		}
+	}
+	if delay := l.take(regular); delay <= 0 || delay > gatewaySendWindow {
+		t.Fatalf("delay = %v, want regular events to wait for the window", delay)
+	}
+
+	// the reserve is left for the priority events
+	for i := 0; i < gatewaySendReserve; i++ {
+		if delay := l.take(gatewaySendLimit); delay != 0 {
+			t.Fatalf("priority event %d waited %v, want it sent right away", i, delay)
+		}
+	}
+	if delay := l.take(gatewaySendLimit); delay == 0 {
+		t.Fatal("priority event went over the limit")
+	}
+}
+
+func TestSendLimiterWindow(t *testing.T) {
+	l := newSendLimiter()
+	now := time.Now()
+	// half the limit was spent a window ago, the other half just now
+	for i := 0; i < gatewaySendLimit; i++ {
+		sentAt := now
+		if i < gatewaySendLimit/2 {
+			sentAt = now.Add(-gatewaySendWindow)
+		}
+		l.sent = append(l.sent, sentAt)
+	}
+
+	for i := 0; i < gatewaySendLimit/2; i++ {
+		if delay := l.take(gatewaySendLimit); delay != 0 {
+			t.Fatalf("event %d waited %v, want the expired tokens back", i, delay)
+		}
+	}
+	if delay := l.take(gatewaySendLimit); delay <= gatewaySendWindow-time.Second {
+		t.Fatalf("delay = %v, want about a window until the next token", delay)
+	}
+}
+
+func TestSendLimiterWaitCancelled(t *testing.T) {
+	l := newSendLimiter()
+	for i := 0; i < gatewaySendLimit; i++ {
+		l.take(gatewaySendLimit)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if l.wait(ctx, true) {
+		t.Fatal("wait() = true on a cancelled context, want false")
+	}
+	if !newSendLimiter().wait(ctx, false) {
+		t.Fatal("wait() = false with tokens left, want true")
+	}
+}
+
+func TestIsPriorityEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "heartbeat", data: `{"op":1,"d":null}`, want: true},
+		{name: "identify", data: `{"op":2,"d":{}}`, want: true},
+		{name: "resume", data: `{"op":6,"d":{}}`, want: true},
+		{name: "presence update", data: `{"op":3,"d":{}}`},
+		{name: "invalid", data: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPriorityEvent([]byte(tt.data)); got != tt.want {
+				t.Fatalf("isPriorityEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
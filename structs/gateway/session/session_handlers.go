@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/cache"
 	"github.com/Carmen-Shannon/simple-discord/structs/dto"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway"
 	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
@@ -21,13 +23,37 @@ import (
 
 func (e *eventHandler) handleInteractionCreateEvent(s ClientSession, p payload.SessionPayload) error {
 	if interactionCreateEvent, ok := p.Data.(receiveevents.InteractionCreateEvent); ok {
+		if interactionCreateEvent.Interaction == nil || interactionCreateEvent.Data == nil {
+			return errors.New("interaction has no data")
+		}
+
+		// routed commands take priority, anything the router doesn't know falls through to the custom handlers.
+		// The handlers run inline, this already runs on the dispatcher so the pool and the ordering apply to them.
+		if router := e.getCommandRouter(); router != nil {
+			ctx := NewInteractionContext(s, p, interactionCreateEvent.Interaction)
+			ctx.translations = router.Translations()
+
+			if handler, ok := router.route(ctx); ok {
+				e.call(s, p, e.wrap(func(s ClientSession, p payload.SessionPayload) error {
+					return handler(ctx)
+				}))
+				return nil
+			}
+		}
+
+		// the custom handler of a command runs the command, so an autocomplete no provider answered gets no choices instead
+		if interactionCreateEvent.Type == structs.ApplicationCommandAutocompleteInteraction {
+			return NewInteractionContext(s, p, interactionCreateEvent.Interaction).Suggest(nil)
+		}
+
 		name := interactionCreateEvent.Data.Name
 		if handler, ok := e.CustomHandlers[name]; ok && handler != nil {
-			go func() {
-				if err := handler(s, p); err != nil {
-					s.Error(err)
-				}
-			}()
+			e.call(s, p, e.wrap(handler))
+			return nil
+		}
+
+		// components and modals are often answered by a collector waiting on them, so a missing handler is expected
+		if interactionCreateEvent.Type == structs.MessageComponentInteraction || interactionCreateEvent.Type == structs.ModalSubmitInteraction {
 			return nil
 		}
 		return errors.New("no handler for interaction")
@@ -36,9 +62,17 @@ func (e *eventHandler) handleInteractionCreateEvent(s ClientSession, p payload.S
 }
 
 func handleSendRequestGuildMembersEvent(s ClientSession, p payload.SessionPayload) error {
-	fmt.Println("HANDLING REQUEST GUILD MEMBERS EVENT")
-	fmt.Println("REQUEST GUILD MEMBERS NOT IMPLEMENTED")
-	return nil
+	if _, ok := p.Data.(sendevents.RequestGuildMembersEvent); ok {
+		requestData, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		s.Write(requestData, false)
+		return nil
+	} else {
+		return errors.New("unexpected payload data type")
+	}
 }
 
 func handleSendVoiceStateUpdateEvent(s ClientSession, p payload.SessionPayload) error {
@@ -60,9 +94,17 @@ func handleSendVoiceStateUpdateEvent(s ClientSession, p payload.SessionPayload)
 }
 
 func handleSendPresenceUpdateEvent(s ClientSession, p payload.SessionPayload) error {
-	fmt.Println("HANDLING PRESENCE UPDATE EVENT")
-	fmt.Println("PRESENCE UPDATE NOT IMPLEMENTED")
-	return nil
+	if _, ok := p.Data.(sendevents.PresenceUpdateEvent); ok {
+		presenceData, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		s.Write(presenceData, false)
+		return nil
+	} else {
+		return errors.New("unexpected payload data type")
+	}
 }
 
 func handleSendResumeEvent(s ClientSession, p payload.SessionPayload) error {
@@ -93,7 +135,8 @@ func handleSendIdentifyEvent(s ClientSession, p payload.SessionPayload) error {
 			Browser: "discord",
 			Device:  "discord",
 		},
-		Intents: structs.GetIntents(s.GetIntents()),
+		Intents:  structs.GetIntents(s.GetIntents()),
+		Presence: s.GetPresence(),
 	}
 	if s.GetShard() != nil && s.GetShards() != nil {
 		identifyEvent.Shard = &[]int{*s.GetShard(), *s.GetShards()}
@@ -114,46 +157,63 @@ func handleSendIdentifyEvent(s ClientSession, p payload.SessionPayload) error {
 
 func handleChannelDeleteEvent(s ClientSession, p payload.SessionPayload) error {
 	if channelDeleteEvent, ok := p.Data.(receiveevents.ChannelDeleteEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[channelDeleteEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-		server.DeleteChannel(channelDeleteEvent.ID)
-		s.AddServer(*server)
+		return s.GetCache().DeleteChannel(channelDeleteEvent.ID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleChannelUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if channelUpdateEvent, ok := p.Data.(receiveevents.ChannelUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[channelUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-		channel := structs.Channel(*channelUpdateEvent.Channel)
-		server.UpdateChannel(channel.ID, channel)
-		s.AddServer(*server)
+		return s.GetCache().SetChannel(*channelUpdateEvent.Channel)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleChannelCreateEvent(s ClientSession, p payload.SessionPayload) error {
 	if channelCreateEvent, ok := p.Data.(receiveevents.ChannelCreateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[channelCreateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		return s.GetCache().SetChannel(*channelCreateEvent.Channel)
+	} else {
+		return errors.New("unexpected payload data type")
+	}
+}
+
+func handleThreadCreateEvent(s ClientSession, p payload.SessionPayload) error {
+	if threadCreateEvent, ok := p.Data.(receiveevents.ThreadCreateEvent); ok {
+		return s.GetCache().SetChannel(*threadCreateEvent.Channel)
+	} else {
+		return errors.New("unexpected payload data type")
+	}
+}
+
+func handleThreadUpdateEvent(s ClientSession, p payload.SessionPayload) error {
+	if threadUpdateEvent, ok := p.Data.(receiveevents.ThreadUpdateEvent); ok {
+		return s.GetCache().SetChannel(*threadUpdateEvent.Channel)
+	} else {
+		return errors.New("unexpected payload data type")
+	}
+}
+
+func handleThreadDeleteEvent(s ClientSession, p payload.SessionPayload) error {
+	if threadDeleteEvent, ok := p.Data.(receiveevents.ThreadDeleteEvent); ok {
+		return s.GetCache().DeleteChannel(threadDeleteEvent.ID)
+	} else {
+		return errors.New("unexpected payload data type")
+	}
+}
+
+func handleThreadListSyncEvent(s ClientSession, p payload.SessionPayload) error {
+	if threadListSyncEvent, ok := p.Data.(receiveevents.ThreadListSyncEvent); ok {
+		c := s.GetCache()
+		for _, thread := range threadListSyncEvent.Threads {
+			if thread.GuildID == nil {
+				thread.GuildID = &threadListSyncEvent.GuildID
+			}
+			if err := c.SetChannel(thread); err != nil {
+				return err
+			}
 		}
-		channel := structs.Channel(*channelCreateEvent.Channel)
-		channel.Typing = structs.NewTypingChannel()
-		server.AddChannel(channel)
-		s.AddServer(*server)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
@@ -162,18 +222,15 @@ func handleChannelCreateEvent(s ClientSession, p payload.SessionPayload) error {
 
 func handlePresenceUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if presenceUpdateEvent, ok := p.Data.(receiveevents.PresenceUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[presenceUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, presenceUpdateEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.UpdatePresence(presenceUpdateEvent.User.ID, *presenceUpdateEvent.PresenceUpdate)
-		s.AddServer(*server)
+		return c.SetPresence(presenceUpdateEvent.GuildID, *presenceUpdateEvent.PresenceUpdate)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleInvalidSessionEvent(s ClientSession, p payload.SessionPayload) error {
@@ -218,19 +275,14 @@ func handleResumedEvent(s ClientSession, p payload.SessionPayload) error {
 
 func handleGuildCreateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildCreateEvent, ok := p.Data.(receiveevents.GuildCreateEvent); ok {
-		if guildCreateEvent.Unavailable != nil && !*guildCreateEvent.Unavailable {
-			server := structs.NewServer(guildCreateEvent.Guild)
-			if err := util.UpdateFields(server, guildCreateEvent.Server); err != nil {
+		if guildCreateEvent.Unavailable == nil || !*guildCreateEvent.Unavailable {
+			if err := s.AddServer(*guildCreateEvent.Server); err != nil {
 				return err
 			}
-			s.AddServer(*server)
+			chunkGuild(s, *guildCreateEvent.Server)
 		}
 	} else if guildCreateUnavailableEvent, ok := p.Data.(receiveevents.GuildCreateUnavailableEvent); ok {
-		unavailableGuild := &structs.Guild{}
-		unavailableGuild.ID = guildCreateUnavailableEvent.ID
-		server := structs.NewServer(unavailableGuild)
-		server.Unavailable = &guildCreateUnavailableEvent.Unavailable
-		s.AddServer(*server)
+		return markUnavailable(s, guildCreateUnavailableEvent.ID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
@@ -240,36 +292,81 @@ func handleGuildCreateEvent(s ClientSession, p payload.SessionPayload) error {
 
 func handleGuildUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildUpdateEvent, ok := p.Data.(receiveevents.GuildUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildUpdateEvent.ID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildUpdateEvent.ID)
+		if err != nil {
+			return err
 		}
 
-		server.UpdateGuild(*guildUpdateEvent.Guild)
-		s.AddServer(*server)
+		return c.SetGuild(shardOf(s), *guildUpdateEvent.Guild)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildDeleteEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildDeleteEvent, ok := p.Data.(receiveevents.GuildDeleteEvent); ok {
-		servers := s.GetServers()
-		_, exists := servers[guildDeleteEvent.ID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildDeleteEvent.ID)
+		if err != nil {
+			return err
 		}
 
-		unavailableGuild := &structs.Guild{}
-		unavailableGuild.ID = guildDeleteEvent.ID
-		server := structs.NewServer(unavailableGuild)
-		server.Unavailable = &guildDeleteEvent.Unavailable
-		s.AddServer(*server)
+		// an unavailable guild is an outage, otherwise the bot was removed from it
+		if guildDeleteEvent.Unavailable {
+			return markUnavailable(s, guildDeleteEvent.ID)
+		}
+		return c.DeleteGuild(guildDeleteEvent.ID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
+}
+
+// guildStore returns the cache store of the session when the guild is cached, the events of guilds that aren't are rejected.
+func guildStore(s ClientSession, guildID structs.Snowflake) (cache.CacheStore, error) {
+	c := s.GetCache()
+	if _, ok, err := c.GetGuildShard(guildID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("server not found")
+	}
+	return c, nil
+}
+
+// markUnavailable drops everything cached for the guild during an outage, keeping the guild known to the shard
+// so it can be found again once `GUILD_CREATE` brings it back.
+func markUnavailable(s ClientSession, guildID structs.Snowflake) error {
+	unavailable := true
+	server := structs.NewServer(&structs.Guild{ID: guildID})
+	server.Unavailable = &unavailable
+	return s.GetCache().SetServer(shardOf(s), *server)
+}
+
+// replaceEmojis replaces the emojis of the guild with the ones of `GUILD_EMOJIS_UPDATE`, which sends all of them.
+func replaceEmojis(c cache.CacheStore, guildID structs.Snowflake, emojis []structs.Emoji) error {
+	kept := make(map[string]struct{}, len(emojis))
+	for _, emoji := range emojis {
+		if emoji.ID == nil {
+			continue
+		}
+		kept[emoji.ID.ToString()] = struct{}{}
+		if err := c.SetEmoji(guildID, emoji); err != nil {
+			return err
+		}
+	}
+
+	var removed []structs.Snowflake
+	if err := c.ScanEmojis(guildID, func(emoji structs.Emoji) bool {
+		if _, ok := kept[emoji.ID.ToString()]; !ok {
+			removed = append(removed, *emoji.ID)
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	for _, id := range removed {
+		if err := c.DeleteEmoji(guildID, id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -287,18 +384,15 @@ func handleGuildBanRemoveEvent(s ClientSession, p payload.SessionPayload) error
 
 func handleGuildEmojisUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildEmojisUpdateEvent, ok := p.Data.(receiveevents.GuildEmojisUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildEmojisUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildEmojisUpdateEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.Emojis = guildEmojisUpdateEvent.Emojis
-		s.AddServer(*server)
+		return replaceEmojis(c, guildEmojisUpdateEvent.GuildID, guildEmojisUpdateEvent.Emojis)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildIntegrationsUpdateEvent(s ClientSession, p payload.SessionPayload) error {
@@ -310,86 +404,98 @@ func handleGuildIntegrationsUpdateEvent(s ClientSession, p payload.SessionPayloa
 
 func handleGuildMemberAddEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildMemberAddEvent, ok := p.Data.(receiveevents.GuildMemberAddEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildMemberAddEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildMemberAddEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.AddMember(*guildMemberAddEvent.GuildMember)
-		s.AddServer(*server)
+		return c.SetMember(guildMemberAddEvent.GuildID, *guildMemberAddEvent.GuildMember)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildMemberRemoveEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildMemberRemoveEvent, ok := p.Data.(receiveevents.GuildMemberRemoveEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildMemberRemoveEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildMemberRemoveEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.DeleteMember(guildMemberRemoveEvent.User.ID)
-		s.AddServer(*server)
+		return c.DeleteMember(guildMemberRemoveEvent.GuildID, guildMemberRemoveEvent.User.ID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildMemberUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildMemberUpdateEvent, ok := p.Data.(receiveevents.GuildMemberUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildMemberUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMember := server.GetMember(guildMemberUpdateEvent.User.ID)
-		if currentMember == nil {
-			return errors.New("user not in server")
+		c, err := guildStore(s, guildMemberUpdateEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		if err := util.UpdateFields(currentMember, &guildMemberUpdateEvent); err != nil {
+		currentMember, err := c.GetMember(guildMemberUpdateEvent.GuildID, guildMemberUpdateEvent.User.ID)
+		if err != nil {
 			return err
+		} else if currentMember == nil {
+			// the update carries the whole member, so a member that isn't cached can be added from it
+			currentMember = &structs.GuildMember{}
 		}
 
-		server.UpdateMember(guildMemberUpdateEvent.User.ID, *currentMember)
-		s.AddServer(*server)
+		return c.SetMember(guildMemberUpdateEvent.GuildID, applyMemberUpdate(*currentMember, guildMemberUpdateEvent))
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
+}
+
+// applyMemberUpdate returns the member with the fields of the update applied, the fields the update leaves out are kept.
+func applyMemberUpdate(member structs.GuildMember, update receiveevents.GuildMemberUpdateEvent) structs.GuildMember {
+	user := update.User
+	member.User = &user
+	member.Roles = update.Roles
+	// a nil nickname, avatar or timeout means it was removed
+	member.Nickname = update.Nick
+	member.Avatar = update.Avatar
+	member.PremiumSince = update.PremiumSince
+	member.TimeoutUntil = update.CommunicationDisabledUntil
+	member.Flags = update.Flags
+	if update.JoinedAt != nil {
+		member.Joined = *update.JoinedAt
+	}
+	if update.IsDeafened != nil {
+		member.IsDeafened = *update.IsDeafened
+	}
+	if update.IsMuted != nil {
+		member.IsMute = *update.IsMuted
+	}
+	if update.IsPending != nil {
+		member.Pending = update.IsPending
+	}
+	if update.AvatarDecorationData != nil {
+		member.AvatarDecorationData = *update.AvatarDecorationData
+	}
+	return member
 }
 
 func handleGuildMembersChunkEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildMembersChunkEvent, ok := p.Data.(receiveevents.GuildMembersChunk); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildMembersChunkEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildMembersChunkEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
 		for _, member := range guildMembersChunkEvent.Members {
-			if server.HasMember(member.User.ID) {
-				server.UpdateMember(member.User.ID, member)
-			} else {
-				server.AddMember(member)
+			if err := c.SetMember(guildMembersChunkEvent.GuildID, member); err != nil {
+				return err
 			}
 		}
 
 		for _, presence := range guildMembersChunkEvent.Presences {
-			if server.HasPresence(presence.User.ID) {
-				server.UpdatePresence(presence.User.ID, *presence.PresenceUpdate)
-			} else {
-				server.AddPresence(*presence.PresenceUpdate)
+			if err := c.SetPresence(guildMembersChunkEvent.GuildID, *presence.PresenceUpdate); err != nil {
+				return err
 			}
 		}
-
-		s.AddServer(*server)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
@@ -398,124 +504,75 @@ func handleGuildMembersChunkEvent(s ClientSession, p payload.SessionPayload) err
 
 func handleGuildRoleCreateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildRoleCreateEvent, ok := p.Data.(receiveevents.GuildRoleCreateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildRoleCreateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildRoleCreateEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.AddRole(guildRoleCreateEvent.Role)
-		s.AddServer(*server)
+		return c.SetRole(guildRoleCreateEvent.GuildID, guildRoleCreateEvent.Role)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildRoleUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildRoleUpdateEvent, ok := p.Data.(receiveevents.GuildRoleUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildRoleUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildRoleUpdateEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.UpdateRole(guildRoleUpdateEvent.Role.ID, guildRoleUpdateEvent.Role)
-		s.AddServer(*server)
+		return c.SetRole(guildRoleUpdateEvent.GuildID, guildRoleUpdateEvent.Role)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleGuildRoleDeleteEvent(s ClientSession, p payload.SessionPayload) error {
 	if guildRoleDeleteEvent, ok := p.Data.(receiveevents.GuildRoleDeleteEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[guildRoleDeleteEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
+		c, err := guildStore(s, guildRoleDeleteEvent.GuildID)
+		if err != nil {
+			return err
 		}
 
-		server.DeleteRole(guildRoleDeleteEvent.RoleID)
-		s.AddServer(*server)
+		return c.DeleteRole(guildRoleDeleteEvent.GuildID, guildRoleDeleteEvent.RoleID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageCreateEvent(s ClientSession, p payload.SessionPayload) error {
 	if messageCreateEvent, ok := p.Data.(receiveevents.MessageCreateEvent); ok {
-		if messageCreateEvent.GuildID != nil {
-			servers := s.GetServers()
-			server, exists := servers[messageCreateEvent.GuildID.ToString()]
-			if !exists {
-				return errors.New("server not found")
-			}
-
-			server.AddMessage(*messageCreateEvent.Message)
-		} else {
-			return nil
-		}
+		return s.GetCache().SetMessage(*messageCreateEvent.Message)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if messageUpdateEvent, ok := p.Data.(receiveevents.MessageUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[messageUpdateEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		if server.GetMessage(messageUpdateEvent.ChannelID, messageUpdateEvent.Message.ID) == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = messageUpdateEvent.ChannelID
-			query.MessageID = messageUpdateEvent.Message.ID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-			server.AddMessage(*message)
-		}
-
-		server.UpdateMessage(*messageUpdateEvent.Message)
+		// message updates carry the whole message, so there is nothing to fetch when it isn't cached
+		return s.GetCache().SetMessage(*messageUpdateEvent.Message)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageDeleteEvent(s ClientSession, p payload.SessionPayload) error {
 	if messageDeleteEvent, ok := p.Data.(receiveevents.MessageDeleteEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[messageDeleteEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		server.DeleteMessage(messageDeleteEvent.ChannelID, messageDeleteEvent.ID)
+		return s.GetCache().DeleteMessage(messageDeleteEvent.ChannelID, messageDeleteEvent.ID)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageBulkDeleteEvent(s ClientSession, p payload.SessionPayload) error {
 	if messageBulkDeleteEvent, ok := p.Data.(receiveevents.MessageDeleteBulkEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[messageBulkDeleteEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
+		c := s.GetCache()
 		for _, id := range messageBulkDeleteEvent.IDs {
-			server.DeleteMessage(messageBulkDeleteEvent.ChannelID, id)
+			if err := c.DeleteMessage(messageBulkDeleteEvent.ChannelID, id); err != nil {
+				return err
+			}
 		}
 	} else {
 		return errors.New("unexpected payload data type")
@@ -523,27 +580,31 @@ func handleMessageBulkDeleteEvent(s ClientSession, p payload.SessionPayload) err
 	return nil
 }
 
-func handleMessageReactionAddEvent(s ClientSession, p payload.SessionPayload) error {
-	if reactionAddEvent, ok := p.Data.(receiveevents.MessageReactionAddEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[reactionAddEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
+// cachedMessage returns the cached message, fetching it from the API when it isn't cached.
+func cachedMessage(s ClientSession, channelID, messageID structs.Snowflake) (*structs.Message, error) {
+	if message, err := s.GetCache().GetMessage(channelID, messageID); err != nil {
+		return nil, err
+	} else if message != nil {
+		return message, nil
+	}
 
-		currentMessage := server.GetMessage(reactionAddEvent.ChannelID, reactionAddEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = reactionAddEvent.ChannelID
-			query.MessageID = reactionAddEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
+	var query dto.GetChannelMessageDto
+	query.ChannelID = channelID
+	query.MessageID = messageID
+	message, err := requestutil.GetChannelMessage(query, *s.GetToken())
+	if err != nil {
+		return nil, err
+	} else if message == nil {
+		return nil, errors.New("message not found")
+	}
+	return message, nil
+}
 
-			currentMessage = message
+func handleMessageReactionAddEvent(s ClientSession, p payload.SessionPayload) error {
+	if reactionAddEvent, ok := p.Data.(receiveevents.MessageReactionAddEvent); ok {
+		currentMessage, err := cachedMessage(s, reactionAddEvent.ChannelID, reactionAddEvent.MessageID)
+		if err != nil {
+			return err
 		}
 
 		// check if there is already a "reaction" cached
@@ -571,34 +632,17 @@ func handleMessageReactionAddEvent(s ClientSession, p payload.SessionPayload) er
 		currentReaction.Count++
 		currentMessage.UpdateReactions(*currentReaction)
 
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageReactionRemoveEvent(s ClientSession, p payload.SessionPayload) error {
 	if reactionRemoveEvent, ok := p.Data.(receiveevents.MessageReactionRemoveEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[reactionRemoveEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMessage := server.GetMessage(reactionRemoveEvent.ChannelID, reactionRemoveEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = reactionRemoveEvent.ChannelID
-			query.MessageID = reactionRemoveEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-
-			currentMessage = message
+		currentMessage, err := cachedMessage(s, reactionRemoveEvent.ChannelID, reactionRemoveEvent.MessageID)
+		if err != nil {
+			return err
 		}
 
 		currentReaction := currentMessage.GetReaction(reactionRemoveEvent.Emoji)
@@ -612,96 +656,45 @@ func handleMessageReactionRemoveEvent(s ClientSession, p payload.SessionPayload)
 			currentMessage.UpdateReactions(*currentReaction)
 		}
 
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageReactionRemoveAllEvent(s ClientSession, p payload.SessionPayload) error {
 	if reactionRemoveAllEvent, ok := p.Data.(receiveevents.MessageReactionRemoveAllEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[reactionRemoveAllEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMessage := server.GetMessage(reactionRemoveAllEvent.ChannelID, reactionRemoveAllEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = reactionRemoveAllEvent.ChannelID
-			query.MessageID = reactionRemoveAllEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-
-			currentMessage = message
+		currentMessage, err := cachedMessage(s, reactionRemoveAllEvent.ChannelID, reactionRemoveAllEvent.MessageID)
+		if err != nil {
+			return err
 		}
 
 		currentMessage.Reactions = []structs.Reaction{}
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessageReactionRemoveEmojiEvent(s ClientSession, p payload.SessionPayload) error {
 	if reactionRemoveEmojiEvent, ok := p.Data.(receiveevents.MessageReactionRemoveEmojiEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[reactionRemoveEmojiEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMessage := server.GetMessage(reactionRemoveEmojiEvent.ChannelID, reactionRemoveEmojiEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = reactionRemoveEmojiEvent.ChannelID
-			query.MessageID = reactionRemoveEmojiEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-
-			currentMessage = message
+		currentMessage, err := cachedMessage(s, reactionRemoveEmojiEvent.ChannelID, reactionRemoveEmojiEvent.MessageID)
+		if err != nil {
+			return err
 		}
 
 		currentMessage.DeleteReaction(reactionRemoveEmojiEvent.Emoji)
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessagePollVoteAddEvent(s ClientSession, p payload.SessionPayload) error {
 	if messagePollVoteAddEvent, ok := p.Data.(receiveevents.MessagePollVoteAddEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[messagePollVoteAddEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMessage := server.GetMessage(messagePollVoteAddEvent.ChannelID, messagePollVoteAddEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = messagePollVoteAddEvent.ChannelID
-			query.MessageID = messagePollVoteAddEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-
-			currentMessage = message
+		currentMessage, err := cachedMessage(s, messagePollVoteAddEvent.ChannelID, messagePollVoteAddEvent.MessageID)
+		if err != nil {
+			return err
 		}
 		if currentMessage.Poll == nil {
 			return errors.New("no poll active")
@@ -713,34 +706,17 @@ func handleMessagePollVoteAddEvent(s ClientSession, p payload.SessionPayload) er
 		}
 
 		currentMessage.Poll.Answers = append(currentMessage.Poll.Answers, *answer)
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleMessagePollVoteRemoveEvent(s ClientSession, p payload.SessionPayload) error {
 	if messagePollVoteRemoveEvent, ok := p.Data.(receiveevents.MessagePollVoteRemoveEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[messagePollVoteRemoveEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentMessage := server.GetMessage(messagePollVoteRemoveEvent.ChannelID, messagePollVoteRemoveEvent.MessageID)
-		if currentMessage == nil {
-			var query dto.GetChannelMessageDto
-			query.ChannelID = messagePollVoteRemoveEvent.ChannelID
-			query.MessageID = messagePollVoteRemoveEvent.MessageID
-			message, err := requestutil.GetChannelMessage(query, *s.GetToken())
-			if err != nil {
-				return err
-			} else if message == nil {
-				return errors.New("message not found")
-			}
-
-			currentMessage = message
+		currentMessage, err := cachedMessage(s, messagePollVoteRemoveEvent.ChannelID, messagePollVoteRemoveEvent.MessageID)
+		if err != nil {
+			return err
 		}
 		if currentMessage.Poll == nil {
 			return errors.New("no poll active")
@@ -753,32 +729,29 @@ func handleMessagePollVoteRemoveEvent(s ClientSession, p payload.SessionPayload)
 			}
 		}
 
-		server.UpdateMessage(*currentMessage)
+		return s.GetCache().SetMessage(*currentMessage)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
-	return nil
 }
 
 func handleTypingStartEvent(s ClientSession, p payload.SessionPayload) error {
 	if typingStartEvent, ok := p.Data.(receiveevents.TypingStartEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[typingStartEvent.GuildID.ToString()]
-		if !exists {
-			return errors.New("server not found")
-		}
-
-		currentChannel := server.GetChannel(typingStartEvent.ChannelID)
-		if currentChannel == nil {
+		c := s.GetCache()
+		currentChannel, err := c.GetChannel(typingStartEvent.ChannelID)
+		if err != nil {
+			return err
+		} else if currentChannel == nil {
 			return errors.New("channel not found")
 		}
 
+		// the in-memory cache shares its typing tracker, other stores get a new one written back
 		if currentChannel.Typing == nil {
 			currentChannel.Typing = structs.NewTypingChannel()
+			currentChannel.Typing.AddUser(typingStartEvent.UserID)
+			return c.SetChannel(*currentChannel)
 		}
 		currentChannel.Typing.AddUser(typingStartEvent.UserID)
-		server.UpdateChannel(typingStartEvent.ChannelID, *currentChannel)
-		s.AddServer(*server)
 	} else {
 		return errors.New("unexpected payload data type")
 	}
@@ -787,18 +760,27 @@ func handleTypingStartEvent(s ClientSession, p payload.SessionPayload) error {
 
 func handleUserUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if userUpdateEvent, ok := p.Data.(receiveevents.UserUpdateEvent); ok {
-		servers := s.GetServers()
-		for _, server := range servers {
-			members := server.GetMembers()
-			for _, member := range members {
-				if member.User.ID.Equals(userUpdateEvent.ID) {
-					if err := util.UpdateFields(member.User, userUpdateEvent); err != nil {
-						return err
-					}
-					server.UpdateMember(userUpdateEvent.ID, member)
-					s.AddServer(*server)
-					break
-				}
+		c := s.GetCache()
+		var guildIDs []structs.Snowflake
+		if err := c.ScanGuilds(func(_ int, guild structs.Guild) bool {
+			guildIDs = append(guildIDs, guild.ID)
+			return true
+		}); err != nil {
+			return err
+		}
+
+		for _, guildID := range guildIDs {
+			member, err := c.GetMember(guildID, userUpdateEvent.ID)
+			if err != nil {
+				return err
+			} else if member == nil {
+				continue
+			}
+			if err := util.UpdateFields(member.User, userUpdateEvent); err != nil {
+				return err
+			}
+			if err := c.SetMember(guildID, *member); err != nil {
+				return err
 			}
 		}
 	} else {
@@ -818,14 +800,23 @@ func handleVoiceChannelEffectSendEvent(s ClientSession, p payload.SessionPayload
 
 func handleVoiceStateUpdateEvent(s ClientSession, p payload.SessionPayload) error {
 	if voiceStateUpdateEvent, ok := p.Data.(receiveevents.VoiceStateUpdateEvent); ok {
-		servers := s.GetServers()
-		server, exists := servers[voiceStateUpdateEvent.GuildID.ToString()]
-		if !exists {
+		if voiceStateUpdateEvent.GuildID == nil {
 			return errors.New("server not found")
 		}
+		c, err := guildStore(s, *voiceStateUpdateEvent.GuildID)
+		if err != nil {
+			return err
+		}
 
-		server.UpdateVoiceState(*voiceStateUpdateEvent.VoiceState)
-		s.AddServer(*server)
+		// a voice state without a channel means the user left
+		if voiceStateUpdateEvent.ChannelID == nil {
+			err = c.DeleteVoiceState(*voiceStateUpdateEvent.GuildID, voiceStateUpdateEvent.UserID)
+		} else {
+			err = c.SetVoiceState(*voiceStateUpdateEvent.GuildID, *voiceStateUpdateEvent.VoiceState)
+		}
+		if err != nil {
+			return err
+		}
 
 		// yuck!!!!
 		if s.GetBotData().UserDetails.ID.Equals(voiceStateUpdateEvent.UserID) {
@@ -890,7 +881,12 @@ func handleVoiceChannelStatusUpdateEvent(s ClientSession, p payload.SessionPaylo
 }
 
 func handleHeartbeatACKEvent(s ClientSession, p payload.SessionPayload) error {
-	return nil
+	if _, ok := p.Data.(receiveevents.HeartbeatACKEvent); ok {
+		heartbeatOf(s).acked()
+		return nil
+	} else {
+		return errors.New("unexpected payload data type")
+	}
 }
 
 func handleReadyEvent(s ClientSession, p payload.SessionPayload) error {
@@ -898,6 +894,9 @@ func handleReadyEvent(s ClientSession, p payload.SessionPayload) error {
 		s.SetSessionID(readyEvent.SessionID)
 		s.SetResumeUrl(readyEvent.ResumeGatewayURL)
 		s.SetBotData(*structs.NewBotData(readyEvent.User, readyEvent.Application))
+		if c, ok := s.GetCache().(interface{ SetSelfID(structs.Snowflake) }); ok {
+			c.SetSelfID(readyEvent.User.ID)
+		}
 		s.CloseReadyReceived()
 	} else {
 		return errors.New("unexpected payload data type")
@@ -942,6 +941,8 @@ func sendHeartbeatEvent(s ClientSession) error {
 		return err
 	}
 
+	// marked before writing, so an ACK arriving before the write returns finds the heartbeat pending
+	heartbeatOf(s).sent()
 	s.Write(heartbeatData, false)
 	return nil
 }
@@ -962,6 +963,17 @@ func heartbeatLoop(ticker *time.Ticker, s ClientSession) {
 				firstHeartbeat = false
 			}
 
+			// no ACK since the last heartbeat means the connection is a zombie, it has to be closed and resumed
+			if heartbeatOf(s).missed() {
+				log.Printf("heartbeat was not acknowledged, resuming the session")
+				if err := s.ResumeSession(); err != nil {
+					if err := s.ReconnectSession(); err != nil {
+						s.Exit(false)
+					}
+				}
+				return
+			}
+
 			if err := sendHeartbeatEvent(s); err != nil {
 				return
 			}
@@ -0,0 +1,430 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// HandleText registers a text command whose handler receives the arguments bound into T.
+// T must be a struct, see `CommandContext.BindArgs` for the supported fields and tags.
+// The usage shown in the help is generated from T when the command has none.
+// When the arguments fail validation the user gets a reply explaining what was wrong, and the handler is not called.
+func HandleText[T any](r *TextCommandRouter, command TextCommand, handler func(ctx *CommandContext, args T) error) error {
+	if command.Usage == "" {
+		command.Usage = argsUsage(reflect.TypeOf((*T)(nil)).Elem())
+	}
+	usage := command.Usage
+
+	command.Handler = func(ctx *CommandContext) error {
+		var args T
+		if err := ctx.BindArgs(&args); err != nil {
+			var optionErr *OptionError
+			if errors.As(err, &optionErr) {
+				return ctx.Reply(fmt.Sprintf("Sorry, I couldn't run that command: %s.\nUsage: `%s`", optionErr.Error(), strings.TrimSpace(ctx.prefix+ctx.Path+" "+usage)))
+			}
+			return err
+		}
+		return handler(ctx, args)
+	}
+	return r.Register(command)
+}
+
+// BindArgs copies the arguments of a text command into the struct pointed to by dst, in the order of its fields.
+// Each field needs a `discord` tag holding the argument name, optionally followed by `required`, or by `rest` to take
+// the rest of the message as is, i.e `discord:"reason,rest"`. Arguments with spaces can be quoted.
+//
+// Supported field types are strings, booleans, integers, floats, `time.Duration` (i.e `7d`, `1h30m`), `structs.Snowflake`,
+// and `structs.User`, `structs.GuildMember`, `structs.Role` and `structs.Channel`, given as a mention, an ID or, for roles
+// and channels, a name. Optional arguments are pointers, and are skipped when the argument doesn't fit, so
+// `!ban @user spamming` leaves an optional duration nil and binds `spamming` to the next field.
+//
+// A missing required argument or an invalid value returns an *OptionError.
+func (c *CommandContext) BindArgs(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a pointer to a struct")
+	}
+	v = v.Elem()
+
+	tokens, err := tokenize(c.rawArgs)
+	if err != nil {
+		return &OptionError{Option: "arguments", Reason: err.Error()}
+	}
+
+	next := 0
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("discord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+
+		if flags == "rest" {
+			var rest string
+			switch {
+			case next == len(tokens)-1:
+				// a single quoted argument loses its quotes, like any other argument
+				rest = tokens[next].value
+			case next < len(tokens):
+				rest = strings.TrimSpace(c.rawArgs[tokens[next].start:])
+			}
+			next = len(tokens)
+			if rest == "" {
+				continue
+			}
+			value, err := c.argValue(name, rest, field.Type)
+			if err != nil {
+				return err
+			}
+			v.Field(i).Set(value)
+			continue
+		}
+
+		if next >= len(tokens) {
+			if flags == "required" {
+				return &OptionError{Option: name, Reason: "is required"}
+			}
+			continue
+		}
+
+		value, err := c.argValue(name, tokens[next].value, field.Type)
+		if err != nil {
+			// optional arguments that don't fit leave the token for the next field
+			var optionErr *OptionError
+			if flags != "required" && field.Type.Kind() == reflect.Ptr && errors.As(err, &optionErr) {
+				continue
+			}
+			return err
+		}
+		v.Field(i).Set(value)
+		next++
+	}
+	return nil
+}
+
+// argValue converts the text argument to the type t.
+func (c *CommandContext) argValue(name, arg string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		value, err := c.argValue(name, arg, t.Elem())
+		if err != nil {
+			return value, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(value)
+		return ptr, nil
+	}
+
+	switch t {
+	case durationType:
+		d, err := ParseDuration(arg)
+		if err != nil {
+			return reflect.Value{}, &OptionError{Option: name, Reason: "must be a duration like 30m, 2h or 7d"}
+		}
+		return reflect.ValueOf(d), nil
+	case snowflakeType:
+		id, ok := parseMention(arg, "@", "@!", "@&", "#")
+		if !ok {
+			return reflect.Value{}, &OptionError{Option: name, Reason: "must be a valid ID"}
+		}
+		return reflect.ValueOf(*id), nil
+	case userType:
+		if member := c.findMember(arg); member != nil && member.User != nil {
+			return reflect.ValueOf(*member.User), nil
+		}
+		if id, ok := parseMention(arg, "@", "@!"); ok {
+			for _, mention := range c.mentions {
+				if mention.User != nil && mention.ID.Equals(*id) {
+					return reflect.ValueOf(*mention.User), nil
+				}
+			}
+		}
+		return reflect.Value{}, &OptionError{Option: name, Reason: "must be a user"}
+	case memberType:
+		if member := c.findMember(arg); member != nil {
+			return reflect.ValueOf(*member), nil
+		}
+		return reflect.Value{}, &OptionError{Option: name, Reason: "must be a member of this server"}
+	case roleType:
+		if role := c.findRole(arg); role != nil {
+			return reflect.ValueOf(*role), nil
+		}
+		return reflect.Value{}, &OptionError{Option: name, Reason: "must be a role"}
+	case channelType:
+		if channel := c.findChannel(arg); channel != nil {
+			return reflect.ValueOf(*channel), nil
+		}
+		return reflect.Value{}, &OptionError{Option: name, Reason: "must be a channel"}
+	}
+
+	value := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		value.SetString(arg)
+	case reflect.Bool:
+		switch strings.ToLower(arg) {
+		case "true", "yes", "y", "on", "1":
+			value.SetBool(true)
+		case "false", "no", "n", "off", "0":
+			value.SetBool(false)
+		default:
+			return value, &OptionError{Option: name, Reason: "must be yes or no"}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.ReplaceAll(arg, ",", ""), 10, t.Bits())
+		if err != nil {
+			return value, &OptionError{Option: name, Reason: "must be a whole number"}
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.ReplaceAll(arg, ",", ""), 10, t.Bits())
+		if err != nil {
+			return value, &OptionError{Option: name, Reason: "must be a positive whole number"}
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.ReplaceAll(arg, ",", ""), t.Bits())
+		if err != nil {
+			return value, &OptionError{Option: name, Reason: "must be a number"}
+		}
+		value.SetFloat(n)
+	default:
+		return value, fmt.Errorf("unsupported argument field type %s for argument %s", t, name)
+	}
+	return value, nil
+}
+
+func (c *CommandContext) findMember(arg string) *structs.GuildMember {
+	id, ok := parseMention(arg, "@", "@!")
+	if !ok {
+		return nil
+	}
+
+	for _, mention := range c.mentions {
+		if mention.User != nil && mention.ID.Equals(*id) {
+			member := mention.Member
+			member.User = mention.User
+			return &member
+		}
+	}
+	if guildID := c.GuildID(); guildID != nil && c.cache() != nil {
+		member, _ := c.cache().GetMember(*guildID, *id)
+		return member
+	}
+	return nil
+}
+
+func (c *CommandContext) findRole(arg string) *structs.Role {
+	guildID := c.GuildID()
+	if guildID == nil || c.cache() == nil {
+		return nil
+	}
+
+	id, byID := parseMention(arg, "@&")
+	if byID {
+		role, _ := c.cache().GetRole(*guildID, *id)
+		return role
+	}
+
+	var found *structs.Role
+	c.cache().ScanRoles(*guildID, func(role structs.Role) bool {
+		if strings.EqualFold(role.Name, strings.TrimPrefix(arg, "@")) {
+			found = &role
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func (c *CommandContext) findChannel(arg string) *structs.Channel {
+	guildID := c.GuildID()
+	if guildID == nil || c.cache() == nil {
+		return nil
+	}
+
+	if id, ok := parseMention(arg, "#"); ok {
+		// only channels of the guild the command was used in
+		if channel, _ := c.cache().GetChannel(*id); channel != nil && channel.GuildID != nil && channel.GuildID.Equals(*guildID) {
+			return channel
+		}
+		return nil
+	}
+
+	name := strings.TrimPrefix(arg, "#")
+	var found *structs.Channel
+	c.cache().ScanChannels(*guildID, func(channel structs.Channel) bool {
+		// threads are found by ID only, their names often repeat
+		if channel.ThreadMetadata == nil && channel.Name != nil && strings.EqualFold(*channel.Name, name) {
+			found = &channel
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// parseMention reads an ID given as is or as a mention with one of the prefixes, i.e `<@&1234>`.
+func parseMention(arg string, prefixes ...string) (*structs.Snowflake, bool) {
+	raw := arg
+	if strings.HasPrefix(arg, "<") && strings.HasSuffix(arg, ">") {
+		inner := arg[1 : len(arg)-1]
+		raw = ""
+		// the longest matching prefix wins, so `@&` isn't read as `@`
+		for _, prefix := range prefixes {
+			if rest, ok := strings.CutPrefix(inner, prefix); ok && (raw == "" || len(rest) < len(raw)) {
+				raw = rest
+			}
+		}
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return structs.NewSnowflake(id), true
+}
+
+// ParseDuration parses durations like `30s`, `1h30m`, `7d` or `2w`, on top of everything `time.ParseDuration` accepts.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+
+	units := map[string]time.Duration{
+		"s": time.Second, "sec": time.Second, "secs": time.Second,
+		"m": time.Minute, "min": time.Minute, "mins": time.Minute,
+		"h": time.Hour, "hr": time.Hour, "hrs": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		s = s[i:]
+
+		j := strings.IndexFunc(s, unicode.IsDigit)
+		if j < 0 {
+			j = len(s)
+		}
+		unit, ok := units[s[:j]]
+		if !ok {
+			return 0, fmt.Errorf("unknown duration unit %q", s[:j])
+		}
+		total += time.Duration(n * float64(unit))
+		s = s[j:]
+	}
+	return total, nil
+}
+
+type argToken struct {
+	value string
+	start int
+}
+
+// tokenize splits the arguments on whitespace, keeping quoted arguments together.
+func tokenize(raw string) ([]argToken, error) {
+	var tokens []argToken
+	var sb strings.Builder
+
+	runes := []rune(raw)
+	offsets := make([]int, len(runes)+1)
+	for i, pos := 0, 0; i < len(runes); i++ {
+		offsets[i] = pos
+		pos += len(string(runes[i]))
+		offsets[i+1] = pos
+	}
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		sb.Reset()
+		if closing, ok := closingQuote(runes[i]); ok {
+			i++
+			for i < len(runes) && runes[i] != closing {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == closing || runes[i+1] == '\\') {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, errors.New("has a quote that is never closed")
+			}
+			i++
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				sb.WriteRune(runes[i])
+				i++
+			}
+		}
+		tokens = append(tokens, argToken{value: sb.String(), start: offsets[start]})
+	}
+	return tokens, nil
+}
+
+func closingQuote(r rune) (rune, bool) {
+	switch r {
+	case '"':
+		return '"', true
+	case '\'':
+		return '\'', true
+	case '“':
+		return '”', true
+	case '«':
+		return '»', true
+	}
+	return 0, false
+}
+
+// argsUsage describes the arguments of T the way the help shows them, i.e `<user> [duration] [reason...]`.
+func argsUsage(t reflect.Type) string {
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	var parts []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("discord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		switch flags {
+		case "required":
+			parts = append(parts, "<"+name+">")
+		case "rest":
+			parts = append(parts, "["+name+"...]")
+		default:
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
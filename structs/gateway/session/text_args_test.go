@@ -0,0 +1,191 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: "  one   two\tthree\n", want: []string{"one", "two", "three"}},
+		{raw: `say "hello world" 'it is' “curly quotes”`, want: []string{"say", "hello world", "it is", "curly quotes"}},
+		{raw: `"escaped \" quote" "back\\slash"`, want: []string{`escaped " quote`, `back\slash`}},
+		{raw: `""`, want: []string{""}},
+		{raw: `don't`, want: []string{"don't"}},
+		{raw: `"never closed`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			tokens, err := tokenize(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenize() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, token := range tokens {
+				got = append(got, token.value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeOffsets(t *testing.T) {
+	raw := `é "ça va" fin`
+	tokens, err := tokenize(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the offsets are byte offsets into the raw string, so the rest of the message can be sliced from it
+	want := []string{`é "ça va" fin`, `"ça va" fin`, `fin`}
+	for i, token := range tokens {
+		if got := raw[token.start:]; got != want[i] {
+			t.Fatalf("token %d starts at %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30s", want: 30 * time.Second},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: " 10 Mins ", wantErr: true},
+		{in: "10mins", want: 10 * time.Minute},
+		{in: "3 days", wantErr: true},
+		{in: "3DAYS", want: 3 * 24 * time.Hour},
+		{in: "250ms", want: 250 * time.Millisecond},
+		{in: "", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "5y", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindArgs(t *testing.T) {
+	type banArgs struct {
+		Target   structs.Snowflake `discord:"target,required"`
+		Duration *time.Duration    `discord:"duration"`
+		Count    *int              `discord:"count"`
+		Reason   string            `discord:"reason,rest"`
+	}
+
+	tests := []struct {
+		name         string
+		raw          string
+		wantDuration *time.Duration
+		wantCount    *int
+		wantReason   string
+	}{
+		{name: "every argument", raw: "<@42> 7d 3 spamming in general", wantDuration: durationPtr(7 * 24 * time.Hour), wantCount: intPtr(3), wantReason: "spamming in general"},
+		{name: "optional arguments skipped", raw: "42 spamming", wantReason: "spamming"},
+		{name: "only some optional arguments", raw: "42 5 spamming", wantCount: intPtr(5), wantReason: "spamming"},
+		{name: "rest keeps its quotes", raw: `42 said "hi" twice`, wantReason: `said "hi" twice`},
+		{name: "single quoted rest loses them", raw: `42 "said hi"`, wantReason: "said hi"},
+		{name: "nothing after the required argument", raw: "<@!42>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &CommandContext{rawArgs: tt.raw}
+			var got banArgs
+			if err := ctx.BindArgs(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Target.ID != 42 {
+				t.Fatalf("target = %v, want 42", got.Target)
+			}
+			if !reflect.DeepEqual(got.Duration, tt.wantDuration) || !reflect.DeepEqual(got.Count, tt.wantCount) {
+				t.Fatalf("duration = %v and count = %v, want %v and %v", got.Duration, got.Count, tt.wantDuration, tt.wantCount)
+			}
+			if got.Reason != tt.wantReason {
+				t.Fatalf("reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestBindArgsErrors(t *testing.T) {
+	type args struct {
+		Target structs.Snowflake `discord:"target,required"`
+		Amount int               `discord:"amount"`
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantOpt string
+	}{
+		{name: "missing required argument", raw: "", wantOpt: "target"},
+		{name: "invalid ID", raw: "someone", wantOpt: "target"},
+		{name: "invalid number for a value field", raw: "42 many", wantOpt: "amount"},
+		{name: "unclosed quote", raw: `42 "5`, wantOpt: "arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &CommandContext{rawArgs: tt.raw}
+
+			var optionErr *OptionError
+			if err := ctx.BindArgs(&args{}); !errors.As(err, &optionErr) {
+				t.Fatalf("BindArgs() = %v, want an *OptionError", err)
+			}
+			if optionErr.Option != tt.wantOpt {
+				t.Fatalf("option = %s, want %s", optionErr.Option, tt.wantOpt)
+			}
+		})
+	}
+}
+
+func TestArgsUsage(t *testing.T) {
+	type args struct {
+		User     structs.Snowflake `discord:"user,required"`
+		Duration *time.Duration    `discord:"duration"`
+		Reason   string            `discord:"reason,rest"`
+		Ignored  string
+	}
+	if got, want := argsUsage(reflect.TypeOf(args{})), "<user> [duration] [reason...]"; got != want {
+		t.Fatalf("argsUsage() = %q, want %q", got, want)
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func intPtr(n int) *int {
+	return &n
+}
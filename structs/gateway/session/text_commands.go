@@ -0,0 +1,418 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+	"github.com/Carmen-Shannon/simple-discord/structs/dto"
+	"github.com/Carmen-Shannon/simple-discord/structs/gateway/payload"
+	receiveevents "github.com/Carmen-Shannon/simple-discord/structs/gateway/receive_events"
+	"github.com/Carmen-Shannon/simple-discord/util/i18n"
+)
+
+const defaultTextPrefix = "!"
+
+// TextCommand declares a prefixed text command, i.e `!ban @user 7d spamming`.
+type TextCommand struct {
+	Name    string
+	Aliases []string
+	// Description is shown in the generated help.
+	Description string
+	// Usage describes the arguments in the generated help, i.e `<user> [duration] [reason...]`.
+	// `HandleText` fills it in from the arguments struct when empty.
+	Usage string
+	// Category groups the command in the generated help.
+	Category string
+	// Hidden leaves the command out of the generated help.
+	Hidden bool
+	// Guards run before the handler, the same as for slash commands.
+	Guards  *Guards
+	Handler CommandHandler
+}
+
+// TextCommandOptions configures a TextCommandRouter.
+type TextCommandOptions struct {
+	// Prefix is used in guilds without a prefix of their own, defaults to `!`.
+	Prefix string
+	// MentionPrefix also accepts a mention of the bot as the prefix, i.e `@bot ban @user`.
+	MentionPrefix bool
+	// GuildPrefixes looks up the prefixes of a guild, i.e from a database, it's called for every message.
+	// Returning no prefixes falls back to the prefixes set with `SetGuildPrefixes`, and then to Prefix.
+	GuildPrefixes func(guildID structs.Snowflake) []string
+	// AllowBots lets other bots run commands, messages from bots are ignored by default.
+	AllowBots bool
+	// DisableHelp removes the generated `help` command.
+	DisableHelp bool
+}
+
+// TextCommandRouter parses MESSAGE_CREATE events into text commands and routes them to their handlers.
+// Text commands share the CommandContext with slash commands, so guards work the same way, and the middleware
+// registered on the session runs around the listener.
+type TextCommandRouter struct {
+	mu       *sync.RWMutex
+	opts     TextCommandOptions
+	commands map[string]*TextCommand
+	aliases  map[string]string
+	prefixes map[string][]string
+
+	translations *i18n.Bundle
+}
+
+// NewTextCommandRouter creates a text command router, hook it up with `Listener`.
+func NewTextCommandRouter(opts TextCommandOptions) *TextCommandRouter {
+	if opts.Prefix == "" {
+		opts.Prefix = defaultTextPrefix
+	}
+	return &TextCommandRouter{
+		mu:       &sync.RWMutex{},
+		opts:     opts,
+		commands: make(map[string]*TextCommand),
+		aliases:  make(map[string]string),
+		prefixes: make(map[string][]string),
+	}
+}
+
+// Register adds the commands to the router, command names and aliases are case-insensitive and must be unique.
+func (r *TextCommandRouter) Register(commands ...TextCommand) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, command := range commands {
+		name := strings.ToLower(command.Name)
+		if name == "" || strings.ContainsAny(name, " \t\n") {
+			return fmt.Errorf("invalid text command name %q", command.Name)
+		}
+		if command.Handler == nil {
+			return fmt.Errorf("text command %q has no handler", command.Name)
+		}
+
+		for _, key := range append([]string{name}, command.Aliases...) {
+			key = strings.ToLower(key)
+			if _, ok := r.commands[key]; ok {
+				return fmt.Errorf("text command %q is already registered", key)
+			}
+			if _, ok := r.aliases[key]; ok {
+				return fmt.Errorf("text command %q is already registered", key)
+			}
+		}
+
+		cmd := command
+		if cmd.Guards != nil {
+			cmd.Handler = Guard(*cmd.Guards, cmd.Handler)
+		}
+		r.commands[name] = &cmd
+		for _, alias := range command.Aliases {
+			r.aliases[strings.ToLower(alias)] = name
+		}
+	}
+	return nil
+}
+
+// Lookup returns the command registered under the name or alias.
+func (r *TextCommandRouter) Lookup(name string) (*TextCommand, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookup(name)
+}
+
+func (r *TextCommandRouter) lookup(name string) (*TextCommand, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := r.aliases[name]; ok {
+		name = alias
+	}
+	command, ok := r.commands[name]
+	return command, ok
+}
+
+// SetGuildPrefixes sets the prefixes of a guild, no prefixes resets the guild to the default prefix.
+func (r *TextCommandRouter) SetGuildPrefixes(guildID structs.Snowflake, prefixes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(prefixes) == 0 {
+		delete(r.prefixes, guildID.ToString())
+		return
+	}
+	r.prefixes[guildID.ToString()] = prefixes
+}
+
+// Prefixes returns the prefixes accepted in the guild, nil for direct messages.
+func (r *TextCommandRouter) Prefixes(guildID *structs.Snowflake) []string {
+	if guildID != nil {
+		if r.opts.GuildPrefixes != nil {
+			if prefixes := r.opts.GuildPrefixes(*guildID); len(prefixes) > 0 {
+				return prefixes
+			}
+		}
+
+		r.mu.RLock()
+		prefixes := r.prefixes[guildID.ToString()]
+		r.mu.RUnlock()
+		if len(prefixes) > 0 {
+			return prefixes
+		}
+	}
+	return []string{r.opts.Prefix}
+}
+
+// SetTranslations sets the bundle used by `CommandContext.T` in text commands, usually the one set on the command router.
+// Messages have no locale, so the commands are translated to the fallback locale of the bundle.
+//
+// Example:
+//
+//	texts.SetTranslations(router.Translations())
+func (r *TextCommandRouter) SetTranslations(bundle *i18n.Bundle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.translations = bundle
+}
+
+// Translations returns the bundle set with `SetTranslations`, nil if there is none.
+func (r *TextCommandRouter) Translations() *i18n.Bundle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.translations
+}
+
+// Listener returns the MESSAGE_CREATE listener running the text commands.
+// If there is a MESSAGE_CREATE listener already, call the returned function from it instead.
+//
+// Example:
+//
+//	type banArgs struct {
+//	    Member   *structs.GuildMember `discord:"user,required"`
+//	    Duration *time.Duration       `discord:"duration"`
+//	    Reason   string               `discord:"reason,rest"`
+//	}
+//
+//	texts := session.NewTextCommandRouter(session.TextCommandOptions{Prefix: "!", MentionPrefix: true})
+//	session.HandleText(texts, session.TextCommand{
+//	    Name:        "ban",
+//	    Description: "Bans a member",
+//	    Guards:      &session.Guards{MemberPermissions: structs.BanMembers, BotPermissions: structs.BanMembers},
+//	}, func(ctx *session.CommandContext, args banArgs) error {
+//	    return ctx.Reply("banned " + args.Member.User.Username)
+//	})
+//	bot.RegisterListeners(map[session.Listener]session.CommandFunc{
+//	    session.MessageCreateListener: texts.Listener(),
+//	})
+func (r *TextCommandRouter) Listener() CommandFunc {
+	return func(s ClientSession, p payload.SessionPayload) error {
+		ev, ok := p.Data.(receiveevents.MessageCreateEvent)
+		if !ok {
+			return errors.New("unexpected payload data type")
+		}
+		if ev.Message == nil {
+			return nil
+		}
+		if !r.opts.AllowBots && ev.Author.IsBot != nil && *ev.Author.IsBot {
+			return nil
+		}
+
+		ctx, command, ok := r.parse(s, p, ev)
+		if !ok {
+			return nil
+		}
+		return command.Handler(ctx)
+	}
+}
+
+// parse finds the prefix and the command of the message, building the context the command runs with.
+func (r *TextCommandRouter) parse(s ClientSession, p payload.SessionPayload, ev receiveevents.MessageCreateEvent) (*CommandContext, *TextCommand, bool) {
+	content := strings.TrimSpace(ev.Content)
+
+	prefix, ok := r.matchPrefix(s, ev.GuildID, content)
+	if !ok {
+		return nil, nil, false
+	}
+	rest := strings.TrimSpace(content[len(prefix):])
+
+	name, rawArgs, _ := strings.Cut(rest, " ")
+	if i := strings.IndexAny(name, "\n\t"); i >= 0 {
+		name, rawArgs = name[:i], rest[i:]
+	}
+	rawArgs = strings.TrimSpace(rawArgs)
+
+	r.mu.RLock()
+	command, ok := r.lookup(name)
+	r.mu.RUnlock()
+	if !ok {
+		if r.opts.DisableHelp || !strings.EqualFold(name, "help") {
+			return nil, nil, false
+		}
+		command = &TextCommand{Name: "help", Handler: r.help}
+	}
+
+	message := ev.Message
+	ctx := &CommandContext{
+		Session:  s,
+		Payload:  p,
+		Path:     strings.ToLower(command.Name),
+		Message:  message,
+		guildID:  ev.GuildID,
+		mentions: ev.Mentions,
+		prefix:   prefix,
+		rawArgs:  rawArgs,
+	}
+	ctx.translations = r.Translations()
+	if ev.Member != nil {
+		member := *ev.Member
+		member.User = &message.Author
+		ctx.member = &member
+	}
+	ctx.respond = func(response structs.InteractionResponseOptions) error {
+		return ctx.sendTextResponse(response)
+	}
+
+	tokens, err := tokenize(rawArgs)
+	if err != nil {
+		// a broken quote shouldn't stop the command from running, BindArgs reports it when the arguments are bound
+		ctx.Args = strings.Fields(rawArgs)
+		return ctx, command, true
+	}
+	for _, token := range tokens {
+		ctx.Args = append(ctx.Args, token.value)
+	}
+	return ctx, command, true
+}
+
+func (r *TextCommandRouter) matchPrefix(s ClientSession, guildID *structs.Snowflake, content string) (string, bool) {
+	if r.opts.MentionPrefix {
+		if botData := s.GetBotData(); botData != nil && botData.UserDetails != nil {
+			id := botData.UserDetails.ID.ToString()
+			for _, mention := range []string{"<@" + id + ">", "<@!" + id + ">"} {
+				if strings.HasPrefix(content, mention) {
+					return mention, true
+				}
+			}
+		}
+	}
+
+	// longest prefix first, so `!!` wins over `!`
+	prefixes := append([]string(nil), r.Prefixes(guildID)...)
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(content, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// sendTextResponse sends the interaction response as a reply to the message that invoked the text command.
+// Text commands have no way to hide a message, so ephemeral responses are sent like any other.
+func (c *CommandContext) sendTextResponse(response structs.InteractionResponseOptions) error {
+	res := response.InteractionResponse()
+	switch res.Type {
+	case structs.DeferredChannelMessageWithSourceInteraction, structs.DeferredUpdatedMessageInteraction:
+		// there is nothing to acknowledge for a message
+		return nil
+	case structs.ChannelMessageWithSourceInteraction, structs.UpdateMessageInteraction:
+	default:
+		return fmt.Errorf("interaction response type %d is not supported for text commands", res.Type)
+	}
+	if res.Data == nil {
+		return errors.New("response has no message")
+	}
+
+	options := dto.NewMessageOptions()
+	options.SetChannelID(c.Message.ChannelID)
+	options.SetTTS(res.Data.TTS)
+	if res.Data.Content != "" {
+		if err := options.SetContent(res.Data.Content); err != nil {
+			return err
+		}
+	}
+	if len(res.Data.Embeds) > 0 {
+		if err := options.SetEmbeds(res.Data.Embeds); err != nil {
+			return err
+		}
+	}
+	if res.Data.AllowedMentions != nil {
+		options.SetAllowedMentions(*res.Data.AllowedMentions)
+	}
+	options.SetComponents(res.Data.Components)
+	if res.Data.Poll != nil {
+		options.SetPoll(*res.Data.Poll)
+	}
+	if c.guildID != nil {
+		if err := options.SetMessageReference(*c.Message, *c.guildID, nil); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.Session.Send(options, false)
+	return err
+}
+
+// Prefix returns the prefix a text command was invoked with, empty for interactions.
+func (c *CommandContext) Prefix() string {
+	return c.prefix
+}
+
+// help lists the commands, or describes a single command when one is named.
+func (r *TextCommandRouter) help(ctx *CommandContext) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(ctx.Args) > 0 {
+		command, ok := r.lookup(ctx.Args[0])
+		if !ok || command.Hidden {
+			return ctx.Reply(fmt.Sprintf("There is no command called `%s`.", ctx.Args[0]))
+		}
+		return ctx.Reply(r.describe(ctx.prefix, command))
+	}
+
+	categories := make(map[string][]*TextCommand)
+	for _, command := range r.commands {
+		if !command.Hidden {
+			categories[command.Category] = append(categories[command.Category], command)
+		}
+	}
+	if len(categories) == 0 {
+		return ctx.Reply("There are no commands.")
+	}
+
+	names := make([]string, 0, len(categories))
+	for category := range categories {
+		names = append(names, category)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, category := range names {
+		title := category
+		if title == "" {
+			title = "Commands"
+		}
+		fmt.Fprintf(&sb, "**%s**\n", title)
+
+		commands := categories[category]
+		sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+		for _, command := range commands {
+			fmt.Fprintf(&sb, "`%s`", strings.TrimSpace(ctx.prefix+command.Name+" "+command.Usage))
+			if command.Description != "" {
+				sb.WriteString(" - " + command.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "Use `%shelp <command>` to learn more about a command.", ctx.prefix)
+	return ctx.Reply(sb.String())
+}
+
+func (r *TextCommandRouter) describe(prefix string, command *TextCommand) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "`%s`\n", strings.TrimSpace(prefix+command.Name+" "+command.Usage))
+	if command.Description != "" {
+		sb.WriteString(command.Description + "\n")
+	}
+	if len(command.Aliases) > 0 {
+		sb.WriteString("Aliases: `" + strings.Join(command.Aliases, "`, `") + "`\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -494,13 +494,13 @@ func (v *voiceSession) speaking(state bool) error {
 	ssrc := v.GetAudioPlayer().GetSession().GetUdpData().SSRC
 	if !state {
 		speakingEvent.SpeakingEvent = &structs.SpeakingEvent{
-			Speaking: structs.Bitfield[structs.SpeakingFlag]{},
+			Speaking: structs.NewBitfield[structs.SpeakingFlag](),
 			Delay:    0,
 			SSRC:     &ssrc,
 		}
 	} else {
 		speakingEvent.SpeakingEvent = &structs.SpeakingEvent{
-			Speaking: structs.Bitfield[structs.SpeakingFlag]{structs.SpeakingFlagMicrophone},
+			Speaking: structs.NewBitfield(structs.SpeakingFlagMicrophone),
 			Delay:    0,
 			SSRC:     &ssrc,
 		}
@@ -86,6 +86,20 @@ const (
 	SurpressRoleSubscriptionPurchaseNotificationRepliesFlag SystemChannelFlag = 1 << 5
 )
 
+var systemChannelFlagNames = map[SystemChannelFlag]string{
+	SurpressJoinNotificationsFlag:                           "SUPPRESS_JOIN_NOTIFICATIONS",
+	SurpressPremiumSubscriptionsFlag:                        "SUPPRESS_PREMIUM_SUBSCRIPTIONS",
+	SurpressGuildReminderNotificationsFlag:                  "SUPPRESS_GUILD_REMINDER_NOTIFICATIONS",
+	SurpressJoinNotificationRepliesFlag:                     "SUPPRESS_JOIN_NOTIFICATION_REPLIES",
+	SurpressRoleSubscriptionPurchaseNotificationsFlag:       "SUPPRESS_ROLE_SUBSCRIPTION_PURCHASE_NOTIFICATIONS",
+	SurpressRoleSubscriptionPurchaseNotificationRepliesFlag: "SUPPRESS_ROLE_SUBSCRIPTION_PURCHASE_NOTIFICATION_REPLIES",
+}
+
+// String returns the Discord names of the set flags.
+func (f SystemChannelFlag) String() string {
+	return flagNames(f, systemChannelFlagNames)
+}
+
 type PremiumTier int
 
 const (
@@ -139,7 +153,7 @@ type Guild struct {
 	DiscoverySplash             *string                         `json:"discovery_splash,omitempty"`
 	Owner                       *bool                           `json:"owner,omitempty"`
 	OwnerID                     Snowflake                       `json:"owner_id"`
-	Permissions                 *string                         `json:"permissions,omitempty"`
+	Permissions                 *Bitfield[Permission]           `json:"permissions,omitempty"`
 	Region                      *string                         `json:"region,omitempty"` //DEPRECATED
 	AFKChannelID                *Snowflake                      `json:"afk_channel_id,omitempty"`
 	AFKTimeout                  int                             `json:"afk_timeout"`
@@ -15,8 +15,8 @@ type GuildMember struct {
 	IsMute               bool                      `json:"mute"`
 	Flags                Bitfield[GuildMemberFlag] `json:"flags"`
 	Pending              *bool                     `json:"pending,omitempty"`
-	Permissions          *string                   `json:"permissions,omitempty"`
-	TimeoutUntil         *time.Time                `json:"timeout_until,omitempty"`
+	Permissions          *Bitfield[Permission]     `json:"permissions,omitempty"`
+	TimeoutUntil         *time.Time                `json:"communication_disabled_until,omitempty"`
 	AvatarDecorationData AvatarDecorationData      `json:"avatar_decoration_data"`
 }
 
@@ -28,3 +28,15 @@ const (
 	GuildMemberFlagBypassesVerification GuildMemberFlag = 1 << 2
 	GuildMemberFlagStartedOnboarding    GuildMemberFlag = 1 << 3
 )
+
+var guildMemberFlagNames = map[GuildMemberFlag]string{
+	GuildMemberFlagDidRejoin:            "DID_REJOIN",
+	GuildMemberFlagCompletedOnboarding:  "COMPLETED_ONBOARDING",
+	GuildMemberFlagBypassesVerification: "BYPASSES_VERIFICATION",
+	GuildMemberFlagStartedOnboarding:    "STARTED_ONBOARDING",
+}
+
+// String returns the Discord names of the set flags.
+func (f GuildMemberFlag) String() string {
+	return flagNames(f, guildMemberFlagNames)
+}
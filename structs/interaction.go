@@ -1,15 +1,83 @@
 package structs
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 )
 
+// ResolvedData holds the entities referenced by an interaction, keyed by their ID as a string.
 type ResolvedData struct {
-	Users       map[Snowflake]User        `json:"users,omitempty"`
-	Members     map[Snowflake]GuildMember `json:"members,omitempty"`
-	Roles       map[Snowflake]Role        `json:"roles,omitempty"`
-	Channels    map[Snowflake]Channel     `json:"channels,omitempty"`
-	Attachments map[Snowflake]Attachment  `json:"attachments,omitempty"`
+	Users       map[string]User        `json:"users,omitempty"`
+	Members     map[string]GuildMember `json:"members,omitempty"`
+	Roles       map[string]Role        `json:"roles,omitempty"`
+	Channels    map[string]Channel     `json:"channels,omitempty"`
+	Attachments map[string]Attachment  `json:"attachments,omitempty"`
+	Messages    map[string]Message     `json:"messages,omitempty"`
+}
+
+func (r *ResolvedData) GetUser(id Snowflake) *User {
+	if r == nil {
+		return nil
+	}
+	if user, ok := r.Users[id.ToString()]; ok {
+		return &user
+	}
+	return nil
+}
+
+// GetMember returns the resolved member with the given ID, the partial member Discord sends is filled in with the resolved user.
+func (r *ResolvedData) GetMember(id Snowflake) *GuildMember {
+	if r == nil {
+		return nil
+	}
+	if member, ok := r.Members[id.ToString()]; ok {
+		if member.User == nil {
+			member.User = r.GetUser(id)
+		}
+		return &member
+	}
+	return nil
+}
+
+func (r *ResolvedData) GetRole(id Snowflake) *Role {
+	if r == nil {
+		return nil
+	}
+	if role, ok := r.Roles[id.ToString()]; ok {
+		return &role
+	}
+	return nil
+}
+
+func (r *ResolvedData) GetChannel(id Snowflake) *Channel {
+	if r == nil {
+		return nil
+	}
+	if channel, ok := r.Channels[id.ToString()]; ok {
+		return &channel
+	}
+	return nil
+}
+
+func (r *ResolvedData) GetMessage(id Snowflake) *Message {
+	if r == nil {
+		return nil
+	}
+	if message, ok := r.Messages[id.ToString()]; ok {
+		return &message
+	}
+	return nil
+}
+
+func (r *ResolvedData) GetAttachment(id Snowflake) *Attachment {
+	if r == nil {
+		return nil
+	}
+	if attachment, ok := r.Attachments[id.ToString()]; ok {
+		return &attachment
+	}
+	return nil
 }
 
 type ApplicationCommandInteractionDataOption struct {
@@ -28,6 +96,12 @@ type InteractionData struct {
 	Options  []ApplicationCommandInteractionDataOption `json:"options,omitempty"`
 	GuildID  *Snowflake                                `json:"guild_id,omitempty"`
 	TargetID *Snowflake                                `json:"target_id,omitempty"`
+
+	// message component and modal submit data
+	CustomID      string               `json:"custom_id,omitempty"`
+	ComponentType MessageComponentType `json:"component_type,omitempty"`
+	Values        []string             `json:"values,omitempty"`
+	Components    []MessageComponent   `json:"components,omitempty"`
 }
 
 type InteractionType int
@@ -72,12 +146,19 @@ const (
 type InteractionResponseData struct {
 	TTS             bool                  `json:"tts"`
 	Content         string                `json:"content,omitempty"`
-	Embeds          []Embed               `json:"embeds.omitempty"`
+	Embeds          []Embed               `json:"embeds,omitempty"`
 	AllowedMentions *AllowedMentions      `json:"allowed_mentions,omitempty"`
 	Flags           Bitfield[MessageFlag] `json:"flags,omitempty"`
 	Components      []MessageComponent    `json:"components,omitempty"`
 	Attachments     []Attachment          `json:"attachments,omitempty"`
 	Poll            *Poll                 `json:"poll,omitempty"`
+
+	// Choices is only used to answer autocomplete interactions.
+	Choices []ApplicationCommandOptionChoice `json:"choices,omitempty"`
+
+	// CustomID and Title are only used to show a modal.
+	CustomID string `json:"custom_id,omitempty"`
+	Title    string `json:"title,omitempty"`
 }
 
 type Interaction struct {
@@ -94,7 +175,7 @@ type Interaction struct {
 	Token                        string                     `json:"token"`
 	Version                      int                        `json:"version"`
 	Message                      *Message                   `json:"message,omitempty"`
-	AppPermissions               string                     `json:"app_permissions"`
+	AppPermissions               Bitfield[Permission]       `json:"app_permissions"`
 	Locale                       *string                    `json:"locale,omitempty"`
 	GuildLocale                  *string                    `json:"guild_locale,omitempty"`
 	Entitlements                 []Entitlement              `json:"entitlements"`
@@ -107,6 +188,28 @@ type InteractionResponse struct {
 	Data *InteractionResponseData `json:"data,omitempty"`
 }
 
+// MarshalJSON always sends the choices of an autocomplete result, Discord rejects the response without them
+// even when there is nothing to suggest.
+func (i InteractionResponse) MarshalJSON() ([]byte, error) {
+	type interactionResponse InteractionResponse
+	if i.Type != ApplicationCommandAutocompleteResultInteraction {
+		return json.Marshal(interactionResponse(i))
+	}
+
+	choices := []ApplicationCommandOptionChoice{}
+	if i.Data != nil && i.Data.Choices != nil {
+		choices = i.Data.Choices
+	}
+	return json.Marshal(struct {
+		Type InteractionResponseType `json:"type"`
+		Data autocompleteResult      `json:"data"`
+	}{Type: i.Type, Data: autocompleteResult{Choices: choices}})
+}
+
+type autocompleteResult struct {
+	Choices []ApplicationCommandOptionChoice `json:"choices"`
+}
+
 type InteractionCallbackResponse struct {
 	Interaction InteractionCallbackObject    `json:"interaction"`
 	Resource    *InteractionCallbackResource `json:"resource,omitempty"`
@@ -142,6 +245,7 @@ type InteractionResponseOptions interface {
 	SetComponents([]MessageComponent)
 	SetAttachments([]Attachment)
 	SetPoll(*Poll)
+	SetChoices([]ApplicationCommandOptionChoice) error
 }
 
 var _ InteractionResponseOptions = (*InteractionResponse)(nil)
@@ -175,7 +279,7 @@ func (i *InteractionResponse) SetAllowedMentions(allowedMentions *AllowedMention
 }
 
 func (i *InteractionResponse) SetFlags(flags Bitfield[MessageFlag]) error {
-	for _, flag := range flags {
+	for _, flag := range flags.Flags() {
 		if flag != EphemeralMessageFlag && flag != SurpressEmbedsMessageFlag && flag != SurpressNotificationsMessageFlag {
 			return errors.New("can only accept SUPRESS_EMBEDS, EPHEMERAL, and SUPRESS_NOTIFICATIONS flags")
 		}
@@ -196,6 +300,49 @@ func (i *InteractionResponse) SetPoll(poll *Poll) {
 	i.Data.Poll = poll
 }
 
+func (i *InteractionResponse) SetChoices(choices []ApplicationCommandOptionChoice) error {
+	if len(choices) > 25 {
+		return errors.New("choices cannot exceed 25")
+	}
+	i.Data.Choices = choices
+	return nil
+}
+
+// NewModal creates the response showing a modal with the given text inputs, each text input is put in its own action row.
+// A modal can have a title of at most 45 characters and between 1 and 5 text inputs.
+func NewModal(customID, title string, inputs ...MessageComponent) (InteractionResponseOptions, error) {
+	if customID == "" || len(customID) > maxCustomIDLength {
+		return nil, fmt.Errorf("modal custom ID must be between 1 and %d characters", maxCustomIDLength)
+	}
+	if title == "" || len(title) > maxModalTitleLength {
+		return nil, fmt.Errorf("modal title must be between 1 and %d characters", maxModalTitleLength)
+	}
+	if len(inputs) == 0 || len(inputs) > maxActionRows {
+		return nil, fmt.Errorf("modals need between 1 and %d text inputs", maxActionRows)
+	}
+
+	rows := make([]MessageComponent, 0, len(inputs))
+	for _, input := range inputs {
+		if input.Type != TextInputMessageComponent {
+			return nil, errors.New("modals can only hold text inputs")
+		}
+		row := NewActionRow(input)
+		if err := row.Validate(); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return &InteractionResponse{
+		Type: ModalInteraction,
+		Data: &InteractionResponseData{
+			CustomID:   customID,
+			Title:      title,
+			Components: rows,
+		},
+	}, nil
+}
+
 func NewInteractionResponseOptions() InteractionResponseOptions {
 	return &InteractionResponse{
 		Type: PongInteraction,
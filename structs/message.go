@@ -12,6 +12,15 @@ const (
 	IsRemix AttachmentFlag = 1 << 2
 )
 
+var attachmentFlagNames = map[AttachmentFlag]string{
+	IsRemix: "IS_REMIX",
+}
+
+// String returns the Discord names of the set flags.
+func (f AttachmentFlag) String() string {
+	return flagNames(f, attachmentFlagNames)
+}
+
 type AllowedMentionType string
 
 const (
@@ -36,6 +45,25 @@ const (
 	IsVoiceMessageMessageFlag           MessageFlag = 1 << 13
 )
 
+var messageFlagNames = map[MessageFlag]string{
+	CrossPostedMessageFlag:              "CROSSPOSTED",
+	IsCrossPostedMessageFlag:            "IS_CROSSPOST",
+	SurpressEmbedsMessageFlag:           "SUPPRESS_EMBEDS",
+	SourceMessageDeletedMessageFlag:     "SOURCE_MESSAGE_DELETED",
+	UrgentMessageFlag:                   "URGENT",
+	HasThreadMessageFlag:                "HAS_THREAD",
+	EphemeralMessageFlag:                "EPHEMERAL",
+	LoadingMessageFlag:                  "LOADING",
+	FailedToMentionSomeRolesMessageFlag: "FAILED_TO_MENTION_SOME_ROLES_IN_THREAD",
+	SurpressNotificationsMessageFlag:    "SUPPRESS_NOTIFICATIONS",
+	IsVoiceMessageMessageFlag:           "IS_VOICE_MESSAGE",
+}
+
+// String returns the Discord names of the set flags.
+func (f MessageFlag) String() string {
+	return flagNames(f, messageFlagNames)
+}
+
 type MessageActivityType int
 
 const (
@@ -234,9 +262,28 @@ const (
 	ChannelSelectMessageComponent     MessageComponentType = 8
 )
 
+// MessageComponent is any interactive component attached to a message or modal.
+// Which fields apply depends on the Type, see the builders in message_components.go.
 type MessageComponent struct {
-	Type       MessageComponentType
-	Components []ActionRow
+	Type          MessageComponentType `json:"type"`
+	CustomID      string               `json:"custom_id,omitempty"`
+	Components    []MessageComponent   `json:"components,omitempty"`
+	Style         int                  `json:"style,omitempty"`
+	Label         string               `json:"label,omitempty"`
+	Emoji         *Emoji               `json:"emoji,omitempty"`
+	URL           string               `json:"url,omitempty"`
+	SkuID         *Snowflake           `json:"sku_id,omitempty"`
+	Disabled      bool                 `json:"disabled,omitempty"`
+	Options       []SelectOption       `json:"options,omitempty"`
+	ChannelTypes  []ChannelType        `json:"channel_types,omitempty"`
+	Placeholder   string               `json:"placeholder,omitempty"`
+	DefaultValues []SelectDefaultValue `json:"default_values,omitempty"`
+	MinValues     *int                 `json:"min_values,omitempty"`
+	MaxValues     *int                 `json:"max_values,omitempty"`
+	MinLength     *int                 `json:"min_length,omitempty"`
+	MaxLength     *int                 `json:"max_length,omitempty"`
+	Required      *bool                `json:"required,omitempty"`
+	Value         string               `json:"value,omitempty"`
 }
 
 type Message struct {
@@ -352,11 +399,6 @@ type RoleSubscriptionData struct {
 	IsRenewal                 bool      `json:"is_renewal"`
 }
 
-type ActionRow struct {
-	Type       MessageComponentType `json:"type"`
-	Components []MessageComponent   `json:"components"`
-}
-
 type ChannelMention struct {
 	ID      Snowflake   `json:"id"`
 	GuildID Snowflake   `json:"guild_id"`
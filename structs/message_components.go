@@ -0,0 +1,312 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ButtonStyle int
+
+const (
+	PrimaryButtonStyle   ButtonStyle = 1
+	SecondaryButtonStyle ButtonStyle = 2
+	SuccessButtonStyle   ButtonStyle = 3
+	DangerButtonStyle    ButtonStyle = 4
+	LinkButtonStyle      ButtonStyle = 5
+	PremiumButtonStyle   ButtonStyle = 6
+)
+
+type TextInputStyle int
+
+const (
+	ShortTextInputStyle     TextInputStyle = 1
+	ParagraphTextInputStyle TextInputStyle = 2
+)
+
+type SelectOption struct {
+	Label       string `json:"label"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Emoji       *Emoji `json:"emoji,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+type SelectDefaultValueType string
+
+const (
+	UserSelectDefaultValue    SelectDefaultValueType = "user"
+	RoleSelectDefaultValue    SelectDefaultValueType = "role"
+	ChannelSelectDefaultValue SelectDefaultValueType = "channel"
+)
+
+type SelectDefaultValue struct {
+	ID   Snowflake              `json:"id"`
+	Type SelectDefaultValueType `json:"type"`
+}
+
+const (
+	maxActionRows         = 5
+	maxButtonsPerRow      = 5
+	maxSelectOptions      = 25
+	maxCustomIDLength     = 100
+	maxButtonLabelLength  = 80
+	maxPlaceholderLength  = 150
+	maxSelectOptionLength = 100
+	maxModalTitleLength   = 45
+	maxTextInputLabel     = 45
+	maxTextInputLength    = 4000
+	maxTextPlaceholder    = 100
+)
+
+// NewActionRow creates an action row holding up to 5 buttons, or a single select menu.
+func NewActionRow(components ...MessageComponent) MessageComponent {
+	return MessageComponent{
+		Type:       ActionRowMessageComponent,
+		Components: components,
+	}
+}
+
+// NewButton creates a button that sends an interaction with the custom ID when clicked.
+// Use `NewLinkButton` for buttons that open a URL.
+func NewButton(style ButtonStyle, label, customID string) MessageComponent {
+	return MessageComponent{
+		Type:     ButtonMessageComponent,
+		Style:    int(style),
+		Label:    label,
+		CustomID: customID,
+	}
+}
+
+// NewLinkButton creates a button that opens the URL when clicked, link buttons don't send an interaction.
+func NewLinkButton(label, url string) MessageComponent {
+	return MessageComponent{
+		Type:  ButtonMessageComponent,
+		Style: int(LinkButtonStyle),
+		Label: label,
+		URL:   url,
+	}
+}
+
+// NewStringSelect creates a select menu with the given options.
+func NewStringSelect(customID string, options ...SelectOption) MessageComponent {
+	return MessageComponent{
+		Type:     StringSelectMessageComponent,
+		CustomID: customID,
+		Options:  options,
+	}
+}
+
+// NewUserSelect creates a select menu listing the users of the guild.
+func NewUserSelect(customID string) MessageComponent {
+	return MessageComponent{
+		Type:     UserSelectMessageComponent,
+		CustomID: customID,
+	}
+}
+
+// NewRoleSelect creates a select menu listing the roles of the guild.
+func NewRoleSelect(customID string) MessageComponent {
+	return MessageComponent{
+		Type:     RoleSelectMessageComponent,
+		CustomID: customID,
+	}
+}
+
+// NewMentionableSelect creates a select menu listing both the users and roles of the guild.
+func NewMentionableSelect(customID string) MessageComponent {
+	return MessageComponent{
+		Type:     MentionableSelectMessageComponent,
+		CustomID: customID,
+	}
+}
+
+// NewChannelSelect creates a select menu listing the channels of the guild, optionally limited to the channel types.
+func NewChannelSelect(customID string, channelTypes ...ChannelType) MessageComponent {
+	return MessageComponent{
+		Type:         ChannelSelectMessageComponent,
+		CustomID:     customID,
+		ChannelTypes: channelTypes,
+	}
+}
+
+// NewTextInput creates a text input for a modal, see `NewModal`.
+func NewTextInput(customID, label string, style TextInputStyle, required bool) MessageComponent {
+	return MessageComponent{
+		Type:     TextInputMessageComponent,
+		CustomID: customID,
+		Label:    label,
+		Style:    int(style),
+		Required: &required,
+	}
+}
+
+// SetLengthRange sets how many characters can be entered in a text input, both must be between 0 and 4000.
+func (c *MessageComponent) SetLengthRange(min, max int) error {
+	if min < 0 || min > maxTextInputLength || max < 1 || max > maxTextInputLength || min > max {
+		return errors.New("text input length must be between 0 and 4000, and min must not exceed max")
+	}
+	c.MinLength = &min
+	c.MaxLength = &max
+	return nil
+}
+
+// SetValueRange sets how many values can be picked in a select menu, both must be between 0 and 25.
+func (c *MessageComponent) SetValueRange(min, max int) error {
+	if min < 0 || min > maxSelectOptions || max < 1 || max > maxSelectOptions || min > max {
+		return errors.New("select values must be between 0 and 25, and min must not exceed max")
+	}
+	c.MinValues = &min
+	c.MaxValues = &max
+	return nil
+}
+
+// ValidateComponents checks the top-level components of a message against Discord's limits.
+func ValidateComponents(components []MessageComponent) error {
+	if len(components) > maxActionRows {
+		return fmt.Errorf("messages can have at most %d action rows", maxActionRows)
+	}
+
+	for _, row := range components {
+		if row.Type != ActionRowMessageComponent {
+			return errors.New("top-level components must be action rows")
+		}
+		if err := row.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the component, and any components inside of it, against Discord's limits.
+func (c *MessageComponent) Validate() error {
+	if len(c.CustomID) > maxCustomIDLength {
+		return fmt.Errorf("custom ID %q exceeds %d characters", c.CustomID, maxCustomIDLength)
+	}
+
+	switch c.Type {
+	case ActionRowMessageComponent:
+		return c.validateActionRow()
+	case ButtonMessageComponent:
+		return c.validateButton()
+	case StringSelectMessageComponent, UserSelectMessageComponent, RoleSelectMessageComponent, MentionableSelectMessageComponent, ChannelSelectMessageComponent:
+		return c.validateSelect()
+	case TextInputMessageComponent:
+		return c.validateTextInput()
+	default:
+		return fmt.Errorf("unknown component type %d", c.Type)
+	}
+}
+
+func (c *MessageComponent) validateTextInput() error {
+	if c.CustomID == "" {
+		return errors.New("text inputs need a custom ID")
+	}
+	if c.Label == "" || len(c.Label) > maxTextInputLabel {
+		return fmt.Errorf("text input label must be between 1 and %d characters", maxTextInputLabel)
+	}
+	if TextInputStyle(c.Style) != ShortTextInputStyle && TextInputStyle(c.Style) != ParagraphTextInputStyle {
+		return fmt.Errorf("unknown text input style %d", c.Style)
+	}
+	if len(c.Placeholder) > maxTextPlaceholder {
+		return fmt.Errorf("text input placeholder exceeds %d characters", maxTextPlaceholder)
+	}
+	if len(c.Value) > maxTextInputLength {
+		return fmt.Errorf("text input value exceeds %d characters", maxTextInputLength)
+	}
+	return nil
+}
+
+func (c *MessageComponent) validateActionRow() error {
+	if len(c.Components) == 0 {
+		return errors.New("action rows must contain at least one component")
+	}
+
+	var buttons, selects, inputs int
+	for i := range c.Components {
+		child := &c.Components[i]
+		switch child.Type {
+		case ActionRowMessageComponent:
+			return errors.New("action rows can't contain other action rows")
+		case ButtonMessageComponent:
+			buttons++
+		case TextInputMessageComponent:
+			inputs++
+		default:
+			selects++
+		}
+
+		if err := child.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if buttons > maxButtonsPerRow {
+		return fmt.Errorf("action rows can hold at most %d buttons", maxButtonsPerRow)
+	}
+	if (selects > 0 || inputs > 0) && buttons+selects+inputs > 1 {
+		return errors.New("action rows with a select menu or text input can't hold anything else")
+	}
+	return nil
+}
+
+func (c *MessageComponent) validateButton() error {
+	if len(c.Label) > maxButtonLabelLength {
+		return fmt.Errorf("button label %q exceeds %d characters", c.Label, maxButtonLabelLength)
+	}
+
+	switch ButtonStyle(c.Style) {
+	case LinkButtonStyle:
+		if c.URL == "" || c.CustomID != "" {
+			return errors.New("link buttons need a URL and can't have a custom ID")
+		}
+	case PremiumButtonStyle:
+		if c.SkuID == nil || c.CustomID != "" || c.URL != "" {
+			return errors.New("premium buttons need a SKU ID and can't have a custom ID or URL")
+		}
+	case PrimaryButtonStyle, SecondaryButtonStyle, SuccessButtonStyle, DangerButtonStyle:
+		if c.CustomID == "" || c.URL != "" {
+			return errors.New("buttons need a custom ID and can't have a URL")
+		}
+	default:
+		return fmt.Errorf("unknown button style %d", c.Style)
+	}
+	return nil
+}
+
+func (c *MessageComponent) validateSelect() error {
+	if c.CustomID == "" {
+		return errors.New("select menus need a custom ID")
+	}
+	if len(c.Placeholder) > maxPlaceholderLength {
+		return fmt.Errorf("placeholder exceeds %d characters", maxPlaceholderLength)
+	}
+	if c.MinValues != nil && (*c.MinValues < 0 || *c.MinValues > maxSelectOptions) {
+		return fmt.Errorf("min values must be between 0 and %d", maxSelectOptions)
+	}
+	if c.MaxValues != nil && (*c.MaxValues < 1 || *c.MaxValues > maxSelectOptions) {
+		return fmt.Errorf("max values must be between 1 and %d", maxSelectOptions)
+	}
+
+	if c.Type != StringSelectMessageComponent {
+		if len(c.Options) > 0 {
+			return errors.New("only string select menus can have options")
+		}
+		return nil
+	}
+
+	if len(c.Options) == 0 || len(c.Options) > maxSelectOptions {
+		return fmt.Errorf("string select menus need between 1 and %d options", maxSelectOptions)
+	}
+	for _, option := range c.Options {
+		if option.Label == "" || len(option.Label) > maxSelectOptionLength || option.Value == "" || len(option.Value) > maxSelectOptionLength {
+			return fmt.Errorf("select option labels and values must be between 1 and %d characters", maxSelectOptionLength)
+		}
+		if len(option.Description) > maxSelectOptionLength {
+			return fmt.Errorf("select option descriptions can't exceed %d characters", maxSelectOptionLength)
+		}
+	}
+	if c.MaxValues != nil && *c.MaxValues > len(c.Options) {
+		return errors.New("max values can't exceed the amount of options")
+	}
+	return nil
+}
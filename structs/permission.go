@@ -1,5 +1,10 @@
 package structs
 
+import (
+	"math"
+	"time"
+)
+
 type Permission int64
 
 const (
@@ -27,7 +32,7 @@ const (
 	Speak                            Permission = 1 << 21
 	MuteMembers                      Permission = 1 << 22
 	DeafenMembers                    Permission = 1 << 23
-	MoveMembers                      Permission = 1 << 23
+	MoveMembers                      Permission = 1 << 24
 	UseVAD                           Permission = 1 << 25
 	ChangeNickname                   Permission = 1 << 26
 	ManageNicknames                  Permission = 1 << 27
@@ -52,4 +57,169 @@ const (
 	SendVoiceMessages                Permission = 1 << 46
 	SendPolls                        Permission = 1 << 49
 	UseExternalApps                  Permission = 1 << 50
+
+	// AllPermissions is what the owner of a guild and administrators have.
+	AllPermissions Permission = math.MaxInt64
+)
+
+// the permissions lost without SendMessages, since they only apply to sending messages
+const sendMessagePermissions = SendTTSMessage | EmbedLinks | AttachFiled | MentionEveryone
+
+// the permissions timed out members keep
+const timeoutPermissions = ViewChannel | ReadMessageHistory
+
+// overwrite types of `Overwrite.Type`
+const (
+	roleOverwrite   = 0
+	memberOverwrite = 1
 )
+
+var permissionFlagNames = map[Permission]string{
+	CreateInstantInvite:              "CREATE_INSTANT_INVITE",
+	KickMembers:                      "KICK_MEMBERS",
+	BanMembers:                       "BAN_MEMBERS",
+	Administrator:                    "ADMINISTRATOR",
+	ManageChannels:                   "MANAGE_CHANNELS",
+	ManageGuild:                      "MANAGE_GUILD",
+	AddReactions:                     "ADD_REACTIONS",
+	ViewAuditLog:                     "VIEW_AUDIT_LOG",
+	PrioritySpeaker:                  "PRIORITY_SPEAKER",
+	Stream:                           "STREAM",
+	ViewChannel:                      "VIEW_CHANNEL",
+	SendMessages:                     "SEND_MESSAGES",
+	SendTTSMessage:                   "SEND_TTS_MESSAGES",
+	ManageMessages:                   "MANAGE_MESSAGES",
+	EmbedLinks:                       "EMBED_LINKS",
+	AttachFiled:                      "ATTACH_FILES",
+	ReadMessageHistory:               "READ_MESSAGE_HISTORY",
+	MentionEveryone:                  "MENTION_EVERYONE",
+	UseExternalEmojis:                "USE_EXTERNAL_EMOJIS",
+	ViewGuildInsights:                "VIEW_GUILD_INSIGHTS",
+	Connect:                          "CONNECT",
+	Speak:                            "SPEAK",
+	MuteMembers:                      "MUTE_MEMBERS",
+	DeafenMembers:                    "DEAFEN_MEMBERS",
+	MoveMembers:                      "MOVE_MEMBERS",
+	UseVAD:                           "USE_VAD",
+	ChangeNickname:                   "CHANGE_NICKNAME",
+	ManageNicknames:                  "MANAGE_NICKNAMES",
+	ManageRoles:                      "MANAGE_ROLES",
+	ManageWebhooks:                   "MANAGE_WEBHOOKS",
+	ManageGuildExpressions:           "MANAGE_GUILD_EXPRESSIONS",
+	UseApplicationCommands:           "USE_APPLICATION_COMMANDS",
+	RequestToSpeak:                   "REQUEST_TO_SPEAK",
+	ManageEvents:                     "MANAGE_EVENTS",
+	ManageThreads:                    "MANAGE_THREADS",
+	CreatePublicThreads:              "CREATE_PUBLIC_THREADS",
+	CreatePrivateThreads:             "CREATE_PRIVATE_THREADS",
+	UseExternalStickers:              "USE_EXTERNAL_STICKERS",
+	SendMessagesInThreads:            "SEND_MESSAGES_IN_THREADS",
+	UseEmbeddedActivities:            "USE_EMBEDDED_ACTIVITIES",
+	ModerateMembers:                  "MODERATE_MEMBERS",
+	ViewCreatorMonetizationAnalytics: "VIEW_CREATOR_MONETIZATION_ANALYTICS",
+	UseSoundboard:                    "USE_SOUNDBOARD",
+	CreateGuildExpressions:           "CREATE_GUILD_EXPRESSIONS",
+	CreateEvents:                     "CREATE_EVENTS",
+	UseExternalSounds:                "USE_EXTERNAL_SOUNDS",
+	SendVoiceMessages:                "SEND_VOICE_MESSAGES",
+	SendPolls:                        "SEND_POLLS",
+	UseExternalApps:                  "USE_EXTERNAL_APPS",
+}
+
+// Names returns the Discord names of the permissions set in p, i.e `MANAGE_MESSAGES`, lowest bit first.
+func (p Permission) Names() []string {
+	return Bitfield[Permission](p).Names()
+}
+
+func (p Permission) String() string {
+	return flagNames(p, permissionFlagNames)
+}
+
+// Has reports whether every permission of permissions is set in p, administrators have them all.
+func (p Permission) Has(permissions Permission) bool {
+	return p&Administrator != 0 || p&permissions == permissions
+}
+
+// ComputePermissions returns the permissions of the member in the channel, following
+// https://discord.com/developers/docs/topics/permissions#permission-overwrites
+//
+// The owner of the guild and administrators have every permission. Otherwise the permissions of the @everyone role and of
+// the roles of the member are combined, then the overwrites of the channel are applied: @everyone, the roles, the member.
+// Without VIEW_CHANNEL the member has no permission in the channel, and without SEND_MESSAGES the permissions that only
+// apply to sending messages are lost. Timed out members only keep VIEW_CHANNEL and READ_MESSAGE_HISTORY.
+//
+// Parameters:
+//   - guild: The guild, with its roles.
+//   - member: The member, with its user.
+//   - channel: The channel, the parent channel for a thread since threads take its overwrites. nil returns the permissions of the member in the guild.
+//
+// Returns:
+//   - Permission: The permissions of the member.
+//
+// Example:
+//
+//	permissions := structs.ComputePermissions(*guild, *member, channel)
+//	if !permissions.Has(structs.SendMessages) {
+//	    return errors.New("missing permissions")
+//	}
+func ComputePermissions(guild Guild, member GuildMember, channel *Channel) Permission {
+	if member.User != nil && guild.OwnerID.Equals(member.User.ID) {
+		return AllPermissions
+	}
+
+	roles := make(map[string]bool, len(member.Roles))
+	for _, id := range member.Roles {
+		roles[id.ToString()] = true
+	}
+
+	var permissions Permission
+	for _, role := range guild.Roles {
+		// the @everyone role has the ID of the guild
+		if role.ID.Equals(guild.ID) || roles[role.ID.ToString()] {
+			permissions |= role.Permissions.Value()
+		}
+	}
+	if permissions&Administrator != 0 {
+		return AllPermissions
+	}
+
+	if channel != nil {
+		permissions = applyOverwrites(permissions, guild.ID, member, roles, channel.PermissionOverwrites)
+		if permissions&ViewChannel == 0 {
+			return 0
+		}
+		if permissions&SendMessages == 0 {
+			permissions &^= sendMessagePermissions
+		}
+	}
+
+	if member.TimeoutUntil != nil && member.TimeoutUntil.After(time.Now()) {
+		permissions &= timeoutPermissions
+	}
+	return permissions
+}
+
+func applyOverwrites(permissions Permission, guildID Snowflake, member GuildMember, roles map[string]bool, overwrites []Overwrite) Permission {
+	var roleAllow, roleDeny Permission
+	var everyone, self *Overwrite
+	for i, overwrite := range overwrites {
+		switch {
+		case overwrite.ID.Equals(guildID):
+			everyone = &overwrites[i]
+		case overwrite.Type == roleOverwrite && roles[overwrite.ID.ToString()]:
+			roleAllow |= overwrite.Allow.Value()
+			roleDeny |= overwrite.Deny.Value()
+		case overwrite.Type == memberOverwrite && member.User != nil && overwrite.ID.Equals(member.User.ID):
+			self = &overwrites[i]
+		}
+	}
+
+	if everyone != nil {
+		permissions = permissions&^everyone.Deny.Value() | everyone.Allow.Value()
+	}
+	permissions = permissions&^roleDeny | roleAllow
+	if self != nil {
+		permissions = permissions&^self.Deny.Value() | self.Allow.Value()
+	}
+	return permissions
+}
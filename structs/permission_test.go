@@ -0,0 +1,176 @@
+package structs
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testGuildID  = 100
+	testOwnerID  = 1
+	testMemberID = 2
+	testModRole  = 10
+	testMuteRole = 11
+)
+
+func testGuild(everyone Permission) Guild {
+	return Guild{
+		ID:      *NewSnowflake(testGuildID),
+		OwnerID: *NewSnowflake(testOwnerID),
+		Roles: []Role{
+			{ID: *NewSnowflake(testGuildID), Permissions: NewBitfield(everyone)},
+			{ID: *NewSnowflake(testModRole), Permissions: NewBitfield(ManageMessages, KickMembers)},
+			{ID: *NewSnowflake(testMuteRole)},
+		},
+	}
+}
+
+func testGuildMember(userID uint64, roles ...uint64) GuildMember {
+	member := GuildMember{User: &User{ID: *NewSnowflake(userID)}}
+	for _, role := range roles {
+		member.Roles = append(member.Roles, *NewSnowflake(role))
+	}
+	return member
+}
+
+func overwrite(id uint64, kind int, allow, deny Permission) Overwrite {
+	return Overwrite{ID: *NewSnowflake(id), Type: kind, Allow: NewBitfield(allow), Deny: NewBitfield(deny)}
+}
+
+func TestComputePermissions(t *testing.T) {
+	everyone := ViewChannel | SendMessages | EmbedLinks | ReadMessageHistory
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		guild      Guild
+		member     GuildMember
+		overwrites []Overwrite
+		// without overwrites the permissions are computed for the guild, with no channel
+		guildOnly bool
+		want      Permission
+	}{
+		{name: "everyone role", guild: testGuild(everyone), member: testGuildMember(testMemberID), guildOnly: true, want: everyone},
+		{
+			name:      "roles add up",
+			guild:     testGuild(everyone),
+			member:    testGuildMember(testMemberID, testModRole),
+			guildOnly: true,
+			want:      everyone | ManageMessages | KickMembers,
+		},
+		{name: "owner has everything", guild: testGuild(0), member: testGuildMember(testOwnerID), want: AllPermissions},
+		{
+			name:       "administrator ignores overwrites",
+			guild:      testGuild(Administrator),
+			member:     testGuildMember(testMemberID),
+			overwrites: []Overwrite{overwrite(testGuildID, roleOverwrite, 0, ViewChannel)},
+			want:       AllPermissions,
+		},
+		{
+			name:       "everyone overwrite",
+			guild:      testGuild(everyone),
+			member:     testGuildMember(testMemberID),
+			overwrites: []Overwrite{overwrite(testGuildID, roleOverwrite, AddReactions, EmbedLinks)},
+			want:       ViewChannel | SendMessages | ReadMessageHistory | AddReactions,
+		},
+		{
+			name:   "role allow wins over role deny",
+			guild:  testGuild(everyone),
+			member: testGuildMember(testMemberID, testModRole, testMuteRole),
+			overwrites: []Overwrite{
+				overwrite(testMuteRole, roleOverwrite, 0, SendMessages|AddReactions),
+				overwrite(testModRole, roleOverwrite, SendMessages, 0),
+			},
+			want: everyone | ManageMessages | KickMembers,
+		},
+		{
+			name:   "member overwrite applies last",
+			guild:  testGuild(everyone),
+			member: testGuildMember(testMemberID, testModRole),
+			overwrites: []Overwrite{
+				overwrite(testModRole, roleOverwrite, AddReactions, 0),
+				overwrite(testMemberID, memberOverwrite, 0, AddReactions|ManageMessages),
+			},
+			want: everyone | KickMembers,
+		},
+		{
+			name:   "overwrites of other roles and members are ignored",
+			guild:  testGuild(everyone),
+			member: testGuildMember(testMemberID),
+			overwrites: []Overwrite{
+				overwrite(testMuteRole, roleOverwrite, 0, ViewChannel),
+				overwrite(testOwnerID, memberOverwrite, 0, ViewChannel),
+			},
+			want: everyone,
+		},
+		{
+			name:       "no permissions without view channel",
+			guild:      testGuild(everyone | KickMembers),
+			member:     testGuildMember(testMemberID),
+			overwrites: []Overwrite{overwrite(testGuildID, roleOverwrite, 0, ViewChannel)},
+			want:       0,
+		},
+		{
+			name:       "message permissions lost without send messages",
+			guild:      testGuild(everyone | MentionEveryone | AttachFiled),
+			member:     testGuildMember(testMemberID),
+			overwrites: []Overwrite{overwrite(testGuildID, roleOverwrite, 0, SendMessages)},
+			want:       ViewChannel | ReadMessageHistory,
+		},
+		{
+			name:  "timed out member only reads",
+			guild: testGuild(everyone),
+			member: func() GuildMember {
+				member := testGuildMember(testMemberID, testModRole)
+				member.TimeoutUntil = &future
+				return member
+			}(),
+			want: ViewChannel | ReadMessageHistory,
+		},
+		{
+			name:  "expired timeout",
+			guild: testGuild(everyone),
+			member: func() GuildMember {
+				member := testGuildMember(testMemberID)
+				member.TimeoutUntil = &past
+				return member
+			}(),
+			want: everyone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var channel *Channel
+			if !tt.guildOnly {
+				channel = &Channel{ID: *NewSnowflake(200), GuildID: NewSnowflake(testGuildID), PermissionOverwrites: tt.overwrites}
+			}
+			if got := ComputePermissions(tt.guild, tt.member, channel); got != tt.want {
+				t.Fatalf("ComputePermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionHas(t *testing.T) {
+	tests := []struct {
+		name        string
+		p           Permission
+		permissions Permission
+		want        bool
+	}{
+		{name: "every permission set", p: SendMessages | EmbedLinks, permissions: SendMessages | EmbedLinks, want: true},
+		{name: "one permission missing", p: SendMessages, permissions: SendMessages | EmbedLinks, want: false},
+		{name: "administrator", p: Administrator, permissions: BanMembers | ManageGuild, want: true},
+		{name: "nothing asked", p: 0, permissions: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Has(tt.permissions); got != tt.want {
+				t.Fatalf("Has() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
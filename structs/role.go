@@ -6,19 +6,28 @@ const (
 	InPrompt RoleFlag = 1 << 0
 )
 
+var roleFlagNames = map[RoleFlag]string{
+	InPrompt: "IN_PROMPT",
+}
+
+// String returns the Discord names of the set flags.
+func (f RoleFlag) String() string {
+	return flagNames(f, roleFlagNames)
+}
+
 type Role struct {
-	ID            Snowflake          `json:"id"`
-	Name          string             `json:"name"`
-	Color         int                `json:"color"`
-	IsHoist       bool               `json:"hoist"`
-	Icon          *string            `json:"icon,omitempty"`
-	UnicodeEmoji  *string            `json:"unicode_emoji,omitempty"`
-	Position      int                `json:"position"`
-	Permissions   string             `json:"permissions"`
-	IsManaged     bool               `json:"managed"`
-	IsMentionable bool               `json:"mentionable"`
-	Tags          RoleTags           `json:"tags"`
-	Flags         Bitfield[RoleFlag] `json:"flags"`
+	ID            Snowflake            `json:"id"`
+	Name          string               `json:"name"`
+	Color         int                  `json:"color"`
+	IsHoist       bool                 `json:"hoist"`
+	Icon          *string              `json:"icon,omitempty"`
+	UnicodeEmoji  *string              `json:"unicode_emoji,omitempty"`
+	Position      int                  `json:"position"`
+	Permissions   Bitfield[Permission] `json:"permissions"`
+	IsManaged     bool                 `json:"managed"`
+	IsMentionable bool                 `json:"mentionable"`
+	Tags          RoleTags             `json:"tags"`
+	Flags         Bitfield[RoleFlag]   `json:"flags"`
 }
 
 type RoleTags struct {
@@ -5,6 +5,8 @@ import (
 	"time"
 )
 
+// Server is a guild with the state `GUILD_CREATE` sends along with it. Sessions keep that state in the cache
+// package, indexed by ID, the servers they return are snapshots of it.
 type Server struct {
 	*Guild
 	JoinedAt             time.Time             `json:"joined_at"`
@@ -20,13 +20,36 @@ const (
 	ActiveDeveloperUserFlag       UserFlag = 1 << 20
 )
 
+var userFlagNames = map[UserFlag]string{
+	StaffUserFlag:                 "STAFF",
+	PartnerUserFlag:               "PARTNER",
+	HypesquadUserFlag:             "HYPESQUAD",
+	BugHunterLevel1UserFlag:       "BUG_HUNTER_LEVEL_1",
+	HypesquadOnlineHouse1UserFlag: "HYPESQUAD_ONLINE_HOUSE_1",
+	HypesquadOnlineHouse2UserFlag: "HYPESQUAD_ONLINE_HOUSE_2",
+	HypesquadOnlineHouse3UserFlag: "HYPESQUAD_ONLINE_HOUSE_3",
+	PremiumEarlySupporterUserFlag: "PREMIUM_EARLY_SUPPORTER",
+	TeamPseudoUserUserFlag:        "TEAM_PSEUDO_USER",
+	BugHunterLevel2UserFlag:       "BUG_HUNTER_LEVEL_2",
+	VerifiedBotUserFlag:           "VERIFIED_BOT",
+	VerifiedDeveloperUserFlag:     "VERIFIED_DEVELOPER",
+	CertifiedModeratorUserFlag:    "CERTIFIED_MODERATOR",
+	BotHttpInteractionsUserFlag:   "BOT_HTTP_INTERACTIONS",
+	ActiveDeveloperUserFlag:       "ACTIVE_DEVELOPER",
+}
+
+// String returns the Discord names of the set flags.
+func (f UserFlag) String() string {
+	return flagNames(f, userFlagNames)
+}
+
 type User struct {
 	ID                   Snowflake            `json:"id"`
 	Username             string               `json:"username"`
 	Discriminator        string               `json:"discriminator"`
 	GlobalName           *string              `json:"global_name,omitempty"`
 	Avatar               *string              `json:"avatar,omitempty"`
-	IsBot                *bool                `json:"is_bot,omitempty"`
+	IsBot                *bool                `json:"bot,omitempty"`
 	IsSystem             *bool                `json:"is_system,omitempty"`
 	IsMFA                *bool                `json:"is_mfa,omitempty"`
 	Banner               *string              `json:"banner,omitempty"`
@@ -39,3 +39,14 @@ const (
 	SpeakingFlagSoundshare SpeakingFlag = 1 << 1
 	SpeakingFlagPriority   SpeakingFlag = 1 << 2
 )
+
+var speakingFlagNames = map[SpeakingFlag]string{
+	SpeakingFlagMicrophone: "MICROPHONE",
+	SpeakingFlagSoundshare: "SOUNDSHARE",
+	SpeakingFlagPriority:   "PRIORITY",
+}
+
+// String returns the Discord names of the set flags.
+func (f SpeakingFlag) String() string {
+	return flagNames(f, speakingFlagNames)
+}
@@ -52,8 +52,8 @@ func BuildQueryString(obj interface{}) string {
 			case structs.Snowflake:
 				snowflakeVal := field.Interface().(structs.Snowflake)
 				val = snowflakeVal.ToString()
-			case structs.Bitfield[any]:
-				bitfieldVal := field.Interface().(structs.Bitfield[any])
+			case interface{ ToString() string }:
+				bitfieldVal := field.Interface().(interface{ ToString() string })
 				val = bitfieldVal.ToString()
 			case *bool:
 				boolVal := field.Interface().(*bool)
@@ -110,8 +110,8 @@ func BuildQueryStringDelimitedSlices(obj interface{}) string {
             case structs.Snowflake:
                 snowflakeVal := field.Interface().(structs.Snowflake)
                 val = snowflakeVal.ToString()
-            case structs.Bitfield[any]:
-                bitfieldVal := field.Interface().(structs.Bitfield[any])
+            case interface{ ToString() string }:
+                bitfieldVal := field.Interface().(interface{ ToString() string })
                 val = bitfieldVal.ToString()
             case *bool:
                 boolVal := field.Interface().(*bool)
@@ -0,0 +1,258 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Carmen-Shannon/simple-discord/structs"
+)
+
+// Bundle holds the translated messages of every locale, keyed by dotted message keys, i.e `commands.ping.description`.
+// A bundle is safe for concurrent use.
+type Bundle struct {
+	mu        *sync.RWMutex
+	fallback  string
+	messages  map[string]map[string]string
+	fallbacks map[string][]string
+}
+
+// NewBundle creates an empty bundle, messages missing from a locale are looked up in the fallback locale, i.e `en-US`.
+func NewBundle(fallback string) *Bundle {
+	return &Bundle{
+		mu:        &sync.RWMutex{},
+		fallback:  fallback,
+		messages:  make(map[string]map[string]string),
+		fallbacks: make(map[string][]string),
+	}
+}
+
+// Add merges the messages into the locale, replacing messages with the same key.
+func (b *Bundle) Add(locale string, messages map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		b.messages[locale][key] = message
+	}
+}
+
+// SetFallbacks sets the locales tried, in order, when a message is missing from the locale,
+// before falling back to the language of the locale and then the fallback locale of the bundle.
+//
+// Example:
+//
+//	bundle.SetFallbacks("es-419", "es-ES")
+func (b *Bundle) SetFallbacks(locale string, fallbacks ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fallbacks[locale] = fallbacks
+}
+
+// LoadFile loads a JSON or TOML file named after its locale, i.e `de.json` or `en-US.toml`.
+// Nested objects and tables are flattened into dotted keys.
+func (b *Bundle) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return b.load(name, data)
+}
+
+// LoadDir loads every JSON and TOML file in the directory, see `LoadFile`.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// LoadFS loads every JSON and TOML file in the directory of the file system, which makes embedding translations possible.
+//
+// Example:
+//
+//	//go:embed locales
+//	var locales embed.FS
+//
+//	bundle := i18n.NewBundle("en-US")
+//	if err := bundle.LoadFS(locales, "locales"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := b.load(entry.Name(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) load(name string, data []byte) error {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	ext := path.Ext(base)
+	locale := strings.TrimSuffix(base, ext)
+	if locale == "" {
+		return fmt.Errorf("can't tell the locale of %s", name)
+	}
+
+	messages := make(map[string]string)
+	switch ext {
+	case ".json":
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if err := flatten("", raw, messages); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	case ".toml":
+		if err := parseTOML(string(data), messages); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("unsupported translation file %s, expected .json or .toml", name)
+	}
+
+	b.Add(locale, messages)
+	return nil
+}
+
+// Message returns the message for the key in the first of the locales that has it, trying the fallbacks of each
+// locale in turn, and finally the fallback locale of the bundle.
+func (b *Bundle) Message(key string, locales ...string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, locale := range b.chain(locales...) {
+		if message, ok := b.messages[locale][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// T translates the key to the locale, formatting the message with the args the same way as fmt.Sprintf.
+// A key missing from every locale is returned as is, so a missing translation is easy to spot.
+func (b *Bundle) T(locale, key string, args ...any) string {
+	message, ok := b.Message(key, locale)
+	if !ok {
+		return key
+	}
+	return Format(message, args...)
+}
+
+// Localizations returns the message for the key in every locale Discord supports, as expected by the
+// `NameLocalizations` and `DescriptionLocalizations` of a command. Locales without the key are left out.
+func (b *Bundle) Localizations(key string) map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var localizations map[string]string
+	for locale, messages := range b.messages {
+		message, ok := messages[key]
+		if !ok || structs.FindLocaleByCode(locale) == nil {
+			continue
+		}
+		if localizations == nil {
+			localizations = make(map[string]string)
+		}
+		localizations[locale] = message
+	}
+	return localizations
+}
+
+// Locales returns the locales loaded into the bundle.
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Fallback returns the locale used when a message is missing from every other locale.
+func (b *Bundle) Fallback() string {
+	return b.fallback
+}
+
+// chain lists the locales to try in order, without duplicates. Must be called with the lock held.
+func (b *Bundle) chain(locales ...string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if locale != "" && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+	var expand func(locale string)
+	expand = func(locale string) {
+		if locale == "" || seen[locale] {
+			return
+		}
+		add(locale)
+		for _, fallback := range b.fallbacks[locale] {
+			expand(fallback)
+		}
+		// pt-BR falls back to pt
+		if language, _, ok := strings.Cut(locale, "-"); ok {
+			expand(language)
+		}
+	}
+
+	for _, locale := range locales {
+		expand(locale)
+	}
+	expand(b.fallback)
+	return chain
+}
+
+// Format formats the message with the args the same way as fmt.Sprintf, leaving messages without args untouched.
+func Format(message string, args ...any) string {
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+func flatten(prefix string, raw map[string]any, messages map[string]string) error {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case string:
+			messages[key] = v
+		case map[string]any:
+			if err := flatten(key, v, messages); err != nil {
+				return err
+			}
+		default:
+			return errors.New("message " + key + " must be a string or an object")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML translation files need: tables, dotted and quoted keys, and string values.
+// Arrays, numbers, booleans and dates aren't messages, so they are rejected.
+func parseTOML(src string, messages map[string]string) error {
+	p := &tomlParser{src: strings.TrimPrefix(src, "\ufeff")}
+	var table []string
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		if p.consume("[") {
+			if p.consume("[") {
+				return p.errorf("arrays of tables are not supported")
+			}
+			keys, err := p.keys()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			if !p.consume("]") {
+				return p.errorf("expected ] to close the table")
+			}
+			table = keys
+		} else {
+			keys, err := p.keys()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			if !p.consume("=") {
+				return p.errorf("expected = after the key")
+			}
+			p.skipSpace()
+			value, err := p.value()
+			if err != nil {
+				return err
+			}
+
+			key := strings.Join(append(append([]string{}, table...), keys...), ".")
+			if _, ok := messages[key]; ok {
+				return p.errorf("duplicate key %s", key)
+			}
+			messages[key] = value
+		}
+
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+type tomlParser struct {
+	src string
+	pos int
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	line := 1 + strings.Count(p.src[:p.pos], "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.src[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.skipComment()
+	}
+	if p.eof() || p.consume("\n") || p.consume("\r\n") {
+		return nil
+	}
+	return p.errorf("unexpected %q after value", p.peek())
+}
+
+func (p *tomlParser) keys() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var key string
+		var err error
+		switch p.peek() {
+		case '"':
+			p.pos++
+			key, err = p.basicString()
+		case '\'':
+			p.pos++
+			key, err = p.literalString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			key = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if !p.consume(".") {
+			return keys, nil
+		}
+	}
+}
+
+func (p *tomlParser) value() (string, error) {
+	switch {
+	case p.consume(`"""`):
+		return p.multilineBasicString()
+	case p.consume(`'''`):
+		return p.multilineLiteralString()
+	case p.consume(`"`):
+		return p.basicString()
+	case p.consume(`'`):
+		return p.literalString()
+	default:
+		return "", p.errorf("only string values are supported")
+	}
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		switch p.peek() {
+		case '\\':
+			// skip the escaped character, unless the line or the file ends right after the backslash
+			p.pos++
+			if !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+			continue
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '"':
+			raw := p.src[start:p.pos]
+			p.pos++
+			return p.unescape(raw, false)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return value, nil
+}
+
+func (p *tomlParser) multilineBasicString() (string, error) {
+	p.trimOpeningNewline()
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\\' {
+			p.pos++
+			if !p.eof() {
+				p.pos++
+			}
+			continue
+		}
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			raw := p.src[start:p.pos]
+			p.pos += 3
+			return p.unescape(raw, true)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) multilineLiteralString() (string, error) {
+	p.trimOpeningNewline()
+	end := strings.Index(p.src[p.pos:], `'''`)
+	if end < 0 {
+		return "", p.errorf("unterminated string")
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.pos += end + 3
+	return strings.ReplaceAll(value, "\r\n", "\n"), nil
+}
+
+// a newline right after the opening delimiter of a multi-line string is not part of it
+func (p *tomlParser) trimOpeningNewline() {
+	if !p.consume("\n") {
+		p.consume("\r\n")
+	}
+}
+
+func (p *tomlParser) unescape(raw string, multiline bool) (string, error) {
+	if multiline {
+		raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			sb.WriteByte(raw[i])
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", p.errorf("invalid escape at the end of a string")
+		}
+
+		switch c := raw[i]; c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'e':
+			sb.WriteByte(0x1b)
+		case '"', '\\':
+			sb.WriteByte(c)
+		case 'u', 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+			if i+size >= len(raw) {
+				return "", p.errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(raw[i+1:i+1+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", p.errorf("invalid unicode escape")
+			}
+			sb.WriteRune(rune(code))
+			i += size
+		case ' ', '\t', '\n':
+			// a backslash at the end of a line in a multi-line string trims the line break and the indentation after it
+			if !multiline {
+				return "", p.errorf("invalid escape \\%c", c)
+			}
+			rest := strings.TrimLeft(raw[i:], " \t")
+			if !strings.HasPrefix(rest, "\n") {
+				return "", p.errorf("invalid escape \\%c", c)
+			}
+			rest = strings.TrimLeft(rest, " \t\n")
+			i = len(raw) - len(rest) - 1
+		default:
+			return "", p.errorf("invalid escape \\%c", c)
+		}
+	}
+	return sb.String(), nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,185 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{
+			name: "bare keys",
+			src:  "greeting = \"hello\"\nfarewell-1 = \"bye\"\n",
+			want: map[string]string{"greeting": "hello", "farewell-1": "bye"},
+		},
+		{
+			name: "comments and blank lines",
+			src:  "# header\n\n  greeting = \"hello\" # trailing\n\n",
+			want: map[string]string{"greeting": "hello"},
+		},
+		{
+			name: "tables",
+			src:  "[commands.ping]\ndescription = \"Ping the bot\"\n\n[errors]\nnot_found = \"Not found\"\n",
+			want: map[string]string{"commands.ping.description": "Ping the bot", "errors.not_found": "Not found"},
+		},
+		{
+			name: "dotted keys",
+			src:  "commands . ping.name = \"ping\"\n",
+			want: map[string]string{"commands.ping.name": "ping"},
+		},
+		{
+			name: "quoted keys",
+			src:  "[\"with space\".'lit.eral']\n\"a.b\" = \"dot\"\n",
+			want: map[string]string{"with space.lit.eral.a.b": "dot"},
+		},
+		{
+			name: "literal string",
+			src:  `path = 'C:\Users\bot'`,
+			want: map[string]string{"path": `C:\Users\bot`},
+		},
+		{
+			name: "multi-line basic string",
+			src:  "text = \"\"\"\nfirst\r\nsecond\"\"\"",
+			want: map[string]string{"text": "first\nsecond"},
+		},
+		{
+			name: "multi-line basic string line ending backslash",
+			src:  "text = \"\"\"\none \\\n    two\"\"\"",
+			want: map[string]string{"text": "one two"},
+		},
+		{
+			name: "multi-line literal string",
+			src:  "text = '''\nraw \\n\r\nline'''",
+			want: map[string]string{"text": "raw \\n\nline"},
+		},
+		{
+			name: "escapes",
+			src:  `text = "tab\tnew\nquote\"slash\\bell\u00e9\U0001F600"`,
+			want: map[string]string{"text": "tab\tnew\nquote\"slash\\bell\u00e9\U0001F600"},
+		},
+		{
+			name: "escaped quote in multi-line string",
+			src:  `text = """say \"""hi\""""`,
+			want: map[string]string{"text": `say """hi"`},
+		},
+		{
+			name: "byte order mark and CRLF",
+			src:  "\ufeffa = \"1\"\r\nb = \"2\"\r\n",
+			want: map[string]string{"a": "1", "b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := map[string]string{}
+			if err := parseTOML(tt.src, messages); err != nil {
+				t.Fatalf("parseTOML() error = %v", err)
+			}
+			if !reflect.DeepEqual(messages, tt.want) {
+				t.Fatalf("parseTOML() = %q, want %q", messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{name: "duplicate key", src: "a = \"1\"\na = \"2\"", err: "line 2: duplicate key a"},
+		{name: "duplicate key across tables", src: "[a]\nb = \"1\"\n[x]\n[a]\nb = \"2\"", err: "duplicate key a.b"},
+		{name: "duplicate dotted and table key", src: "a.b = \"1\"\n[a]\nb = \"2\"", err: "duplicate key a.b"},
+		{name: "integer", src: "a = 1", err: "only string values are supported"},
+		{name: "boolean", src: "a = true", err: "only string values are supported"},
+		{name: "array", src: "a = [\"1\"]", err: "only string values are supported"},
+		{name: "inline table", src: "a = { b = \"1\" }", err: "only string values are supported"},
+		{name: "date", src: "a = 2024-01-01", err: "only string values are supported"},
+		{name: "array of tables", src: "[[a]]", err: "arrays of tables are not supported"},
+		{name: "unclosed table", src: "[a\nb = \"1\"", err: "expected ] to close the table"},
+		{name: "missing key", src: "= \"1\"", err: "expected a key"},
+		{name: "missing equals", src: "a \"1\"", err: "expected = after the key"},
+		{name: "trailing value", src: "a = \"1\" \"2\"", err: "after value"},
+		{name: "unterminated string", src: "a = \"1", err: "unterminated string"},
+		{name: "string spanning lines", src: "a = \"1\nb = \"2\"", err: "line 1: unterminated string"},
+		{name: "backslash before newline", src: "a = \"1\\\n\"", err: "line 1: unterminated string"},
+		{name: "backslash at end of file", src: "a = \"1\\", err: "unterminated string"},
+		{name: "backslash at end of multi-line file", src: "a = \"\"\"1\\", err: "unterminated string"},
+		{name: "unterminated literal string", src: "a = '1\n'", err: "unterminated string"},
+		{name: "unterminated multi-line literal string", src: "a = '''1", err: "unterminated string"},
+		{name: "invalid escape", src: `a = "\q"`, err: `invalid escape \q`},
+		{name: "line ending backslash in basic string", src: "a = \"\\ \"", err: `invalid escape \ `},
+		{name: "short unicode escape", src: `a = "\u12"`, err: "invalid unicode escape"},
+		{name: "surrogate unicode escape", src: `a = "\uD800"`, err: "invalid unicode escape"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseTOML(tt.src, map[string]string{})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseTOML() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBundleChain(t *testing.T) {
+	bundle := NewBundle("en-US")
+	bundle.SetFallbacks("es-419", "es-MX", "es-ES")
+	bundle.SetFallbacks("es-MX", "es-419")
+
+	tests := []struct {
+		name    string
+		locales []string
+		want    []string
+	}{
+		{name: "no locale", want: []string{"en-US", "en"}},
+		{name: "language", locales: []string{"de"}, want: []string{"de", "en-US", "en"}},
+		{name: "region", locales: []string{"pt-BR"}, want: []string{"pt-BR", "pt", "en-US", "en"}},
+		{
+			name:    "explicit fallbacks before the language",
+			locales: []string{"es-419"},
+			want:    []string{"es-419", "es-MX", "es", "es-ES", "en-US", "en"},
+		},
+		{
+			name:    "several locales",
+			locales: []string{"fr", "en-GB", "fr"},
+			want:    []string{"fr", "en-GB", "en", "en-US"},
+		},
+		{name: "empty locale", locales: []string{""}, want: []string{"en-US", "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle.mu.RLock()
+			defer bundle.mu.RUnlock()
+			if chain := bundle.chain(tt.locales...); !reflect.DeepEqual(chain, tt.want) {
+				t.Fatalf("chain(%q) = %q, want %q", tt.locales, chain, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundleMessageFallback(t *testing.T) {
+	bundle := NewBundle("en-US")
+	bundle.SetFallbacks("es-419", "es-ES")
+	bundle.Add("en-US", map[string]string{"a": "en-US a", "b": "en-US b", "c": "en-US c", "d": "en-US d"})
+	bundle.Add("es", map[string]string{"a": "es a", "b": "es b", "c": "es c"})
+	bundle.Add("es-ES", map[string]string{"a": "es-ES a", "b": "es-ES b"})
+	bundle.Add("es-419", map[string]string{"a": "es-419 a"})
+
+	for key, want := range map[string]string{"a": "es-419 a", "b": "es-ES b", "c": "es c", "d": "en-US d"} {
+		if message := bundle.T("es-419", key); message != want {
+			t.Errorf("T(es-419, %s) = %q, want %q", key, message, want)
+		}
+	}
+	if message := bundle.T("es-419", "missing"); message != "missing" {
+		t.Errorf("T(es-419, missing) = %q, want the key", message)
+	}
+}
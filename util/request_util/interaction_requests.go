@@ -36,3 +36,28 @@ func CreateInteractionResponse(interactionID, interactionToken, token string, dt
 
 	return nil, err
 }
+
+// EditOriginalInteractionResponse edits the initial response to an interaction, this works for ephemeral responses as well.
+// Interaction tokens are valid for 15 minutes.
+func EditOriginalInteractionResponse(applicationID, interactionToken string, data structs.InteractionResponseData) (*structs.Message, error) {
+	path := "/webhooks/" + applicationID + "/" + interactionToken + "/messages/@original"
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := HttpRequest("PATCH", path, headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var message structs.Message
+	if err := json.Unmarshal(resp, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}